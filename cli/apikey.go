@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+	"time"
+	"url-shortener/config"
+	"url-shortener/services"
+)
+
+const apiKeyUsage = "usage: apikey create <name> [--admin] | list | revoke <id>"
+
+func runAPIKey(args []string) error {
+	if len(args) == 0 {
+		return errors.New(apiKeyUsage)
+	}
+	if config.StorageDriver() == config.DriverMemory {
+		return errors.New("API keys can't be managed from the command line with the memory storage driver; set ADMIN_API_KEY instead")
+	}
+
+	storage, err := config.SetupStorage()
+	if err != nil {
+		return err
+	}
+	apiKeys := services.NewAPIKeyService(storage.APIKeys)
+
+	switch args[0] {
+	case "create":
+		if len(args) < 2 {
+			return errors.New(apiKeyUsage)
+		}
+		isAdmin := len(args) > 2 && args[2] == "--admin"
+		plaintext, key, err := apiKeys.CreateKey(args[1], isAdmin)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Created API key %d (%s, admin=%t)\n", key.ID, key.Name, key.IsAdmin)
+		fmt.Fprintf(out, "Key: %s\n", plaintext)
+		fmt.Fprintln(out, "Store it now; it can't be shown again.")
+		return nil
+	case "list":
+		keys, err := apiKeys.ListKeys()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tPREFIX\tADMIN\tCREATED AT\tREVOKED AT")
+		for _, key := range keys {
+			revokedAt := "-"
+			if key.RevokedAt != nil {
+				revokedAt = key.RevokedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s…\t%t\t%s\t%s\n", key.ID, key.Name, key.Prefix, key.IsAdmin, key.CreatedAt.Format(time.RFC3339), revokedAt)
+		}
+		return w.Flush()
+	case "revoke":
+		if len(args) < 2 {
+			return errors.New(apiKeyUsage)
+		}
+		id, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid key id %q", args[1])
+		}
+		if err := apiKeys.RevokeKey(uint(id)); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Revoked API key %d\n", id)
+		return nil
+	default:
+		return fmt.Errorf("unknown apikey command %q (expected create, list or revoke)", args[0])
+	}
+}
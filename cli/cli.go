@@ -0,0 +1,46 @@
+// Package cli implements the administrative subcommands of the url-shortener binary,
+// e.g. `url-shortener migrate up`.
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// out is where commands write their output; tests may replace it.
+var out io.Writer = os.Stdout
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"migrate": {usage: "migrate up|down [steps]|status", run: runMigrate},
+	"apikey":  {usage: "apikey create <name> [--admin] | list | revoke <id>", run: runAPIKey},
+	"links":   {usage: "links export [flags] | import [flags] <file|-> | purge", run: runLinks},
+}
+
+// Run executes the subcommand named by args[0].
+func Run(args []string) error {
+	if len(args) == 0 {
+		return usageError()
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return usageError()
+	}
+	return cmd.run(args[1:])
+}
+
+func usageError() error {
+	usages := make([]string, 0, len(commands))
+	for _, cmd := range commands {
+		usages = append(usages, "  "+cmd.usage)
+	}
+	sort.Strings(usages)
+	return fmt.Errorf("usage: url-shortener <command>\ncommands:\n%s", strings.Join(usages, "\n"))
+}
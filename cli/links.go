@@ -0,0 +1,186 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"url-shortener/config"
+	"url-shortener/models"
+	"url-shortener/services"
+	"url-shortener/utils"
+)
+
+const linksUsage = "usage: links export [--format csv|jsonl] [--output file] [filters] | import [--format csv|jsonl] [--conflict skip|overwrite|rename] [--dry-run] <file|-> | purge"
+
+// operator is the identity the command line acts as: an admin, since it has direct access
+// to the store anyway.
+var operator = &models.APIKey{Name: "cli", IsAdmin: true}
+
+func runLinks(args []string) error {
+	if len(args) == 0 {
+		return errors.New(linksUsage)
+	}
+	if config.StorageDriver() == config.DriverMemory {
+		return errors.New("links can't be managed from the command line with the memory storage driver")
+	}
+
+	switch args[0] {
+	case "export":
+		return runLinksExport(args[1:])
+	case "import":
+		return runLinksImport(args[1:])
+	case "purge":
+		return runLinksPurge(args[1:])
+	default:
+		return fmt.Errorf("unknown links command %q (expected export, import or purge)", args[0])
+	}
+}
+
+func runLinksExport(args []string) error {
+	flags := flag.NewFlagSet("links export", flag.ContinueOnError)
+	flags.SetOutput(out)
+	format := flags.String("format", "jsonl", "csv or jsonl")
+	output := flags.String("output", "", "file to write instead of stdout")
+	createdAfter := flags.String("created-after", "", "only links created at or after this time (RFC 3339 or YYYY-MM-DD)")
+	createdBefore := flags.String("created-before", "", "only links created before this time")
+	var filter services.LinkFilter
+	flags.StringVar(&filter.Status, "status", "", "active, expired or all")
+	flags.StringVar(&filter.Domain, "domain", "", "destination host")
+	flags.StringVar(&filter.SlugPrefix, "slug-prefix", "", "short link prefix")
+	flags.StringVar(&filter.Search, "q", "", "substring of the destination URL")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	transferFormat, err := services.ParseTransferFormat(*format)
+	if err != nil {
+		return err
+	}
+	if filter.CreatedAfter, err = utils.ParseTimestamp(*createdAfter); err != nil {
+		return fmt.Errorf("invalid --created-after %q", *createdAfter)
+	}
+	if filter.CreatedBefore, err = utils.ParseTimestamp(*createdBefore); err != nil {
+		return fmt.Errorf("invalid --created-before %q", *createdBefore)
+	}
+
+	storage, err := config.SetupStorage()
+	if err != nil {
+		return err
+	}
+
+	w := out
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	return services.NewLinkTransferService(storage.URLs, nil, nil, services.ExpirationPolicy{}).Export(w, transferFormat, filter, operator)
+}
+
+func runLinksImport(args []string) error {
+	flags := flag.NewFlagSet("links import", flag.ContinueOnError)
+	flags.SetOutput(out)
+	format := flags.String("format", "", "csv or jsonl (default: from the file extension)")
+	conflict := flags.String("conflict", "skip", "what to do with existing short links: skip, overwrite or rename")
+	dryRun := flags.Bool("dry-run", false, "validate and report without writing anything")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New(linksUsage)
+	}
+	path := flags.Arg(0)
+
+	if *format == "" {
+		*format = string(services.FormatJSONL)
+		if strings.HasSuffix(strings.ToLower(path), ".csv") {
+			*format = string(services.FormatCSV)
+		}
+	}
+	transferFormat, err := services.ParseTransferFormat(*format)
+	if err != nil {
+		return err
+	}
+	policy, err := services.ParseConflictPolicy(*conflict)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	storage, err := config.SetupStorage()
+	if err != nil {
+		return err
+	}
+	// Rows without a short link get slugs like the server would generate
+	slugs, err := services.NewSlugGenerator(services.SlugGeneratorConfig(config.LoadSlugConfig()), storage.Sequences)
+	if err != nil {
+		return fmt.Errorf("invalid slug settings: %w", err)
+	}
+	reservedSlugs, err := services.NewSlugRegistry(storage.Reserved, services.SlugRegistryConfig(config.LoadSlugRegistryConfig()))
+	if err != nil {
+		return fmt.Errorf("invalid reserved slug settings: %w", err)
+	}
+	expiry := services.ExpirationPolicy(config.LoadExpirationPolicy())
+	report, err := services.NewLinkTransferService(storage.URLs, slugs, reservedSlugs, expiry).Import(r, services.ImportOptions{
+		Format:   transferFormat,
+		Conflict: policy,
+		DryRun:   *dryRun,
+	}, operator)
+	if err != nil {
+		return err
+	}
+
+	if report.DryRun {
+		fmt.Fprintln(out, "Dry run: nothing was written")
+	}
+	fmt.Fprintf(out, "Created %d, overwritten %d, renamed %d, skipped %d, failed %d\n",
+		report.Created, report.Overwritten, report.Renamed, report.Skipped, report.Failed)
+	if len(report.Rows) == 0 {
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LINE\tACTION\tSHORT LINK\tDETAILS")
+	for _, row := range report.Rows {
+		details := row.Error
+		if row.RenamedFrom != "" {
+			details = "renamed from " + row.RenamedFrom
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", row.Line, row.Action, row.ShortLink, details)
+	}
+	return w.Flush()
+}
+
+// runLinksPurge hard-deletes links whose tombstone period (LINK_TOMBSTONE_PERIOD) has passed,
+// freeing their slugs.
+func runLinksPurge(args []string) error {
+	if len(args) != 0 {
+		return errors.New(linksUsage)
+	}
+	storage, err := config.SetupStorage()
+	if err != nil {
+		return err
+	}
+	lifecycleConfig := services.LinkLifecycleConfig(config.LoadLinkLifecycleConfig())
+	report, err := services.NewLinkLifecycleService(storage.URLs, storage.Clicks, lifecycleConfig).Purge()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Purged %d links and %d clicks\n", report.Links, report.Clicks)
+	return nil
+}
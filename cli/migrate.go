@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"text/tabwriter"
+	"time"
+	"url-shortener/config"
+	"url-shortener/migrations"
+)
+
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up|down [steps]|status")
+	}
+	if config.StorageDriver() == config.DriverMemory {
+		return errors.New("migrations require a SQL storage driver (mysql or sqlite)")
+	}
+
+	db, err := config.OpenDatabase()
+	if err != nil {
+		return err
+	}
+	migrator := migrations.NewMigrator(db)
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		printMigrations("Applied", applied)
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid steps %q: must be a positive integer", args[1])
+			}
+		}
+		reverted, err := migrator.Down(steps)
+		printMigrations("Reverted", reverted)
+		return err
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q (expected up, down or status)", args[0])
+	}
+}
+
+func printMigrations(verb string, ran []migrations.Migration) {
+	if len(ran) == 0 {
+		fmt.Fprintln(out, "No migrations to run")
+		return
+	}
+	for _, migration := range ran {
+		fmt.Fprintf(out, "%s %04d_%s\n", verb, migration.Version, migration.Name)
+	}
+}
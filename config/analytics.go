@@ -0,0 +1,44 @@
+package config
+
+import (
+	"os"
+	"time"
+	"url-shortener/logging"
+)
+
+// AnalyticsConfig holds settings for click tracking.
+type AnalyticsConfig struct {
+	// IPSalt keys the hash applied to client IPs before they are stored.
+	IPSalt string
+
+	// AsyncClicks routes clicks through the batched background recorder instead of
+	// writing them in the redirect request.
+	AsyncClicks   bool
+	BufferSize    int
+	BatchSize     int
+	FlushInterval time.Duration
+	Workers       int
+	// Backpressure is "drop" or "block", applied when the click buffer is full.
+	Backpressure string
+}
+
+// LoadAnalyticsConfig reads click tracking settings from the environment
+func LoadAnalyticsConfig() AnalyticsConfig {
+	cfg := AnalyticsConfig{
+		IPSalt:        os.Getenv("ANALYTICS_IP_SALT"),
+		AsyncClicks:   envBool("CLICK_ASYNC", true),
+		BufferSize:    envInt("CLICK_BUFFER_SIZE", 10000),
+		BatchSize:     envInt("CLICK_BATCH_SIZE", 100),
+		FlushInterval: envDuration("CLICK_FLUSH_INTERVAL", time.Second),
+		Workers:       envInt("CLICK_WORKERS", 2),
+		Backpressure:  envString("CLICK_BACKPRESSURE", "drop"),
+	}
+	if cfg.IPSalt == "" {
+		logging.Log.Warn("ANALYTICS_IP_SALT is not set; hashed client IPs are unsalted")
+	}
+	if cfg.Backpressure != "drop" && cfg.Backpressure != "block" {
+		logging.Log.WithField("value", cfg.Backpressure).Warn("CLICK_BACKPRESSURE defaulted to drop")
+		cfg.Backpressure = "drop"
+	}
+	return cfg
+}
@@ -0,0 +1,26 @@
+package config
+
+import "time"
+
+// URLCacheConfig mirrors repositories.URLCacheConfig so it can be converted directly.
+type URLCacheConfig struct {
+	Size        int
+	LocalTTL    time.Duration
+	RedisTTL    time.Duration
+	NegativeTTL time.Duration
+}
+
+// URLCacheEnabled reports whether short link lookups are cached (URL_CACHE_ENABLED)
+func URLCacheEnabled() bool {
+	return envBool("URL_CACHE_ENABLED", true)
+}
+
+// LoadURLCacheConfig reads link cache sizes and lifetimes from the environment
+func LoadURLCacheConfig() URLCacheConfig {
+	return URLCacheConfig{
+		Size:        envInt("URL_CACHE_SIZE", 10000),
+		LocalTTL:    envDuration("URL_CACHE_LOCAL_TTL", 30*time.Second),
+		RedisTTL:    envDuration("URL_CACHE_REDIS_TTL", 10*time.Minute),
+		NegativeTTL: envDuration("URL_CACHE_NEGATIVE_TTL", 10*time.Second),
+	}
+}
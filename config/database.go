@@ -3,35 +3,145 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
 	"url-shortener/logging"
-	"url-shortener/models"
+	"url-shortener/migrations"
+	"url-shortener/repositories"
 
+	"github.com/glebarez/sqlite"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
-// SetupDatabase initializes and returns a database connection
+// Supported values for STORAGE_DRIVER
+const (
+	DriverMySQL  = "mysql"
+	DriverSQLite = "sqlite"
+	DriverMemory = "memory"
+)
+
+// defaultSQLitePath is used when SQLITE_PATH is unset
+const defaultSQLitePath = "url-shortener.db"
+
+// Storage bundles the repositories for the configured storage driver.
+type Storage struct {
+	Driver    string
+	DB        *gorm.DB // nil for the memory driver
+	URLs      repositories.URLRepository
+	Clicks    repositories.ClickRepository
+	APIKeys   repositories.APIKeyRepository
+	Sequences repositories.SequenceRepository
+	Reserved  repositories.ReservedSlugRepository
+	Locks     repositories.LockRepository
+	Redis     *redis.Client                     // nil without REDIS_HOST
+	URLCache  *repositories.CachedURLRepository // Also URLs; nil when lookups aren't cached
+}
+
+// StorageDriver returns the configured STORAGE_DRIVER, defaulting to MySQL
+func StorageDriver() string {
+	driver := strings.ToLower(os.Getenv("STORAGE_DRIVER"))
+	if driver == "" {
+		return DriverMySQL
+	}
+	return driver
+}
+
+// SetupStorage initializes the repositories for the configured storage driver
+func SetupStorage() (*Storage, error) {
+	redisClient, err := SetupRedis()
+	if err != nil {
+		logging.Log.WithError(err).Error("Failed to connect to Redis")
+		return nil, err
+	}
+
+	driver := StorageDriver()
+	if driver == DriverMemory {
+		logging.Log.Warn("Using in-memory storage; data will be lost on restart")
+		return &Storage{
+			Driver:    driver,
+			URLs:      repositories.NewMemoryURLRepository(),
+			Clicks:    repositories.NewMemoryClickRepository(),
+			APIKeys:   repositories.NewMemoryAPIKeyRepository(),
+			Sequences: repositories.NewMemorySequenceRepository(),
+			Reserved:  repositories.NewMemoryReservedSlugRepository(),
+			Locks:     repositories.NewMemoryLockRepository(),
+			Redis:     redisClient,
+		}, nil
+	}
+
+	db, err := SetupDatabase()
+	if err != nil {
+		return nil, err
+	}
+	storage := &Storage{
+		Driver:    driver,
+		DB:        db,
+		URLs:      repositories.NewURLRepository(db),
+		Clicks:    repositories.NewClickRepository(db),
+		APIKeys:   repositories.NewAPIKeyRepository(db),
+		Sequences: repositories.NewSequenceRepository(db),
+		Reserved:  repositories.NewReservedSlugRepository(db),
+		Locks:     repositories.NewLockRepository(db),
+		Redis:     redisClient,
+	}
+	// The memory driver is as fast as a cache, so only database lookups are cached
+	if URLCacheEnabled() {
+		storage.URLCache = repositories.NewCachedURLRepository(storage.URLs, redisClient, repositories.URLCacheConfig(LoadURLCacheConfig()))
+		storage.URLs = storage.URLCache
+	}
+	return storage, nil
+}
+
+// SetupDatabase opens the database and applies pending migrations unless MIGRATE_ON_START=false
 func SetupDatabase() (*gorm.DB, error) {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_NAME"),
-	)
-
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	db, err := OpenDatabase()
 	if err != nil {
-		logging.Log.WithError(err).Error("Failed to connect to database")
 		return nil, err
 	}
 
-	// Use models.URL for AutoMigrate
-	err = db.AutoMigrate(&models.URL{})
+	if strings.EqualFold(os.Getenv("MIGRATE_ON_START"), "false") {
+		logging.Log.Info("Skipping migrations at startup (MIGRATE_ON_START=false)")
+		return db, nil
+	}
+
+	applied, err := migrations.NewMigrator(db).Up()
 	if err != nil {
 		logging.Log.WithError(err).Error("Failed to migrate database")
 		return nil, err
 	}
-	logging.Log.Info("Database migration completed successfully")
+	logging.Log.WithField("applied", len(applied)).Info("Database migration completed successfully")
+	return db, nil
+}
+
+// OpenDatabase returns a connection for the SQL storage drivers without touching the schema
+func OpenDatabase() (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch driver := StorageDriver(); driver {
+	case DriverMySQL:
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+			os.Getenv("DB_USER"),
+			os.Getenv("DB_PASSWORD"),
+			os.Getenv("DB_HOST"),
+			os.Getenv("DB_PORT"),
+			os.Getenv("DB_NAME"),
+		)
+		dialector = mysql.Open(dsn)
+	case DriverSQLite:
+		path := os.Getenv("SQLITE_PATH")
+		if path == "" {
+			path = defaultSQLitePath
+		}
+		dialector = sqlite.Open(path)
+	default:
+		return nil, fmt.Errorf("unsupported STORAGE_DRIVER %q (expected %s, %s or %s)", driver, DriverMySQL, DriverSQLite, DriverMemory)
+	}
+
+	// TranslateError turns unique violations into gorm.ErrDuplicatedKey on every driver
+	db, err := gorm.Open(dialector, &gorm.Config{TranslateError: true})
+	if err != nil {
+		logging.Log.WithError(err).Error("Failed to connect to database")
+		return nil, err
+	}
 	return db, nil
 }
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"url-shortener/logging"
+)
+
+// envInt reads an integer environment variable, falling back to def if unset or invalid
+func envInt(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		logging.Log.WithError(err).WithField("value", value).Warnf("%s defaulted", name)
+		return def
+	}
+	return parsed
+}
+
+// envFloat reads a floating-point environment variable, falling back to def if unset or invalid
+func envFloat(name string, def float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		logging.Log.WithError(err).WithField("value", value).Warnf("%s defaulted", name)
+		return def
+	}
+	return parsed
+}
+
+// envDuration reads a Go duration (e.g. "500ms", "2m") from the environment
+func envDuration(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		logging.Log.WithError(err).WithField("value", value).Warnf("%s defaulted", name)
+		return def
+	}
+	return parsed
+}
+
+// envBool reads a boolean environment variable ("true", "1", "false", "0", ...)
+func envBool(name string, def bool) bool {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		logging.Log.WithError(err).WithField("value", value).Warnf("%s defaulted", name)
+		return def
+	}
+	return parsed
+}
+
+// envString reads a lower-cased string environment variable
+func envString(name, def string) string {
+	value := strings.ToLower(strings.TrimSpace(os.Getenv(name)))
+	if value == "" {
+		return def
+	}
+	return value
+}
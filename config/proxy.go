@@ -0,0 +1,23 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// ClientIPConfig mirrors middleware.ClientIPConfig so it can be converted directly.
+type ClientIPConfig struct {
+	TrustedProxies []string
+	RealIPHeader   string
+}
+
+// LoadClientIPConfig reads which proxies may report client addresses, and how, from the environment
+func LoadClientIPConfig() ClientIPConfig {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return ClientIPConfig{TrustedProxies: proxies, RealIPHeader: strings.TrimSpace(os.Getenv("REAL_IP_HEADER"))}
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"url-shortener/logging"
+)
+
+// Supported values for RATE_LIMIT_STORE
+const (
+	RateLimitStoreMemory = "memory"
+	RateLimitStoreRedis  = "redis"
+)
+
+// RateLimit mirrors middleware.RateLimit so it can be converted directly.
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
+	Burst    int
+}
+
+// RateLimitPolicy holds the limits of one route group.
+type RateLimitPolicy struct {
+	PerIP  RateLimit
+	PerKey RateLimit
+}
+
+// MemoryRateLimitConfig mirrors middleware.MemoryRateLimitConfig so it can be converted directly.
+type MemoryRateLimitConfig struct {
+	MaxVisitors     int
+	Shards          int
+	JanitorInterval time.Duration
+}
+
+// RateLimitConfig holds the rate limiter's settings.
+type RateLimitConfig struct {
+	Store    string                // memory (per replica) or redis (shared)
+	Memory   MemoryRateLimitConfig // Only for the memory store
+	Redirect RateLimitPolicy       // Following short links
+	Create   RateLimitPolicy       // Creating and importing links
+	Admin    RateLimitPolicy       // Admin endpoints
+	Default  RateLimitPolicy       // Every other route
+	Auth     RateLimit             // Requests presenting an API key, per IP, before the key is checked
+}
+
+// LoadRateLimitConfig reads rate limiter settings from the environment. Each group takes a
+// per-IP limit from RATE_LIMIT_<GROUP> and a per-API-key limit from RATE_LIMIT_<GROUP>_PER_KEY,
+// which defaults to the per-IP one. MAX_REQUESTS_PER_MINUTE per RATE_LIMIT_WINDOW_SECONDS is
+// the default for link creation and every other route.
+func LoadRateLimitConfig() RateLimitConfig {
+	legacy := RateLimit{
+		Requests: envInt("MAX_REQUESTS_PER_MINUTE", 40),
+		Window:   time.Duration(envInt("RATE_LIMIT_WINDOW_SECONDS", 60)) * time.Second,
+	}
+	if legacy.Requests <= 0 {
+		legacy.Requests = 40
+	}
+	if legacy.Window <= 0 {
+		legacy.Window = time.Minute
+	}
+
+	return RateLimitConfig{
+		Store: envString("RATE_LIMIT_STORE", RateLimitStoreMemory),
+		Memory: MemoryRateLimitConfig{
+			MaxVisitors:     envInt("RATE_LIMIT_MAX_CLIENTS", 100000),
+			Shards:          envInt("RATE_LIMIT_SHARDS", 64),
+			JanitorInterval: envDuration("RATE_LIMIT_JANITOR_INTERVAL", time.Minute),
+		},
+		Redirect: envRateLimitPolicy("RATE_LIMIT_REDIRECT", RateLimit{Requests: 300, Window: time.Minute, Burst: 60}),
+		Create:   envRateLimitPolicy("RATE_LIMIT_CREATE", legacy),
+		Admin:    envRateLimitPolicy("RATE_LIMIT_ADMIN", RateLimit{Requests: 120, Window: time.Minute}),
+		Default:  envRateLimitPolicy("RATE_LIMIT_DEFAULT", legacy),
+		Auth:     envRateLimit("RATE_LIMIT_AUTH", RateLimit{Requests: 300, Window: time.Minute, Burst: 60}),
+	}
+}
+
+func envRateLimitPolicy(name string, def RateLimit) RateLimitPolicy {
+	perIP := envRateLimit(name, def)
+	return RateLimitPolicy{PerIP: perIP, PerKey: envRateLimit(name+"_PER_KEY", perIP)}
+}
+
+// envRateLimit reads a limit written as requests/window[/burst], e.g. "300/1m/60"
+func envRateLimit(name string, def RateLimit) RateLimit {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return def
+	}
+	limit, err := parseRateLimit(value)
+	if err != nil {
+		logging.Log.WithError(err).WithField("value", value).Warnf("%s defaulted", name)
+		return def
+	}
+	return limit
+}
+
+func parseRateLimit(value string) (RateLimit, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return RateLimit{}, errors.New("expected requests/window[/burst]")
+	}
+	var limit RateLimit
+	var err error
+	if limit.Requests, err = strconv.Atoi(parts[0]); err != nil || limit.Requests <= 0 {
+		return RateLimit{}, errors.New("requests must be a positive integer")
+	}
+	if limit.Window, err = time.ParseDuration(parts[1]); err != nil || limit.Window <= 0 {
+		return RateLimit{}, errors.New("window must be a positive duration such as 1m")
+	}
+	if len(parts) == 3 {
+		if limit.Burst, err = strconv.Atoi(parts[2]); err != nil || limit.Burst <= 0 {
+			return RateLimit{}, errors.New("burst must be a positive integer")
+		}
+	}
+	return limit, nil
+}
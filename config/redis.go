@@ -0,0 +1,42 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SetupRedis connects to REDIS_HOST, or returns nil if it is unset: Redis is optional, and
+// features that can use it fall back to in-process state.
+func SetupRedis() (*redis.Client, error) {
+	host := strings.TrimSpace(os.Getenv("REDIS_HOST"))
+	if host == "" {
+		return nil, nil
+	}
+	port := os.Getenv("REDIS_PORT")
+	if port == "" {
+		port = "6379"
+	}
+	// Redis sits in front of the database, so a slow one must not be slower than no cache
+	timeout := envDuration("REDIS_TIMEOUT", 500*time.Millisecond)
+	client := redis.NewClient(&redis.Options{
+		Addr:         host + ":" + port,
+		Password:     os.Getenv("REDIS_PASSWORD"),
+		DB:           envInt("REDIS_DB", 0),
+		DialTimeout:  timeout,
+		ReadTimeout:  timeout,
+		WriteTimeout: timeout,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("connecting to Redis at %s: %w", client.Options().Addr, err)
+	}
+	return client, nil
+}
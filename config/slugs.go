@@ -0,0 +1,59 @@
+package config
+
+import (
+	"os"
+	"time"
+	"url-shortener/logging"
+)
+
+// SlugConfig holds settings for generated slugs. Its fields mirror
+// services.SlugGeneratorConfig so it converts directly.
+type SlugConfig struct {
+	// Strategy is "random", "sequential" or "words".
+	Strategy  string
+	Length    int
+	MaxLength int
+	// Alphabet is case-sensitive, unlike most settings, so it is read verbatim.
+	Alphabet string
+	// Length grows by one when more than GrowthThreshold of the last GrowthWindow
+	// generated slugs were already taken.
+	GrowthThreshold float64
+	GrowthWindow    int
+	// Secret keys the shuffle of sequential slugs and BlockSize is how many values an
+	// instance leases at a time.
+	Secret    string
+	BlockSize int64
+}
+
+// LoadSlugConfig reads slug generation settings from the environment
+func LoadSlugConfig() SlugConfig {
+	cfg := SlugConfig{
+		Strategy:        envString("SLUG_STRATEGY", "random"),
+		Length:          envInt("SLUG_LENGTH", 6),
+		MaxLength:       envInt("SLUG_MAX_LENGTH", 10),
+		Alphabet:        os.Getenv("SLUG_ALPHABET"),
+		GrowthThreshold: envFloat("SLUG_GROWTH_THRESHOLD", 0.1),
+		GrowthWindow:    envInt("SLUG_GROWTH_WINDOW", 100),
+		Secret:          os.Getenv("SLUG_SECRET"),
+		BlockSize:       int64(envInt("SLUG_BLOCK_SIZE", 100)),
+	}
+	if cfg.Strategy == "sequential" && cfg.Secret == "" {
+		logging.Log.Warn("SLUG_SECRET is not set; sequential slugs follow a publicly known order")
+	}
+	return cfg
+}
+
+// SlugRegistryConfig mirrors services.SlugRegistryConfig so it converts directly.
+type SlugRegistryConfig struct {
+	// BlocklistFile lists blocked words and "re:" patterns, one per line
+	BlocklistFile   string
+	RefreshInterval time.Duration
+}
+
+// LoadSlugRegistryConfig reads reserved slug settings from the environment
+func LoadSlugRegistryConfig() SlugRegistryConfig {
+	return SlugRegistryConfig{
+		BlocklistFile:   os.Getenv("SLUG_BLOCKLIST_FILE"),
+		RefreshInterval: envDuration("SLUG_RESERVED_REFRESH", 30*time.Second),
+	}
+}
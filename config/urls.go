@@ -0,0 +1,104 @@
+package config
+
+import (
+	"os"
+	"time"
+	"url-shortener/logging"
+)
+
+// URLConfig holds settings for link management endpoints.
+type URLConfig struct {
+	// BulkMaxItems caps the number of items accepted by one bulk create request.
+	BulkMaxItems int
+	// ImportMaxBytes caps the size of an import file uploaded through the API.
+	ImportMaxBytes int64
+}
+
+// LoadURLConfig reads link management settings from the environment
+func LoadURLConfig() URLConfig {
+	return URLConfig{
+		BulkMaxItems:   envInt("BULK_MAX_ITEMS", 100),
+		ImportMaxBytes: int64(envInt("IMPORT_MAX_BYTES", 10<<20)),
+	}
+}
+
+// LinkLifecycleConfig mirrors services.LinkLifecycleConfig so it can be converted directly.
+type LinkLifecycleConfig struct {
+	TombstonePeriod time.Duration
+	BatchSize       int
+}
+
+// LoadLinkLifecycleConfig reads how long deleted and expired links are kept before purging
+func LoadLinkLifecycleConfig() LinkLifecycleConfig {
+	return LinkLifecycleConfig{
+		TombstonePeriod: envDuration("LINK_TOMBSTONE_PERIOD", 7*24*time.Hour),
+		BatchSize:       envInt("LINK_PURGE_BATCH_SIZE", 500),
+	}
+}
+
+// ExpirySweeperConfig mirrors services.ExpirySweeperConfig so it can be converted directly.
+type ExpirySweeperConfig struct {
+	Interval   time.Duration
+	BatchSize  int
+	MaxBatches int
+	LeaseTTL   time.Duration
+}
+
+// ExpirySweeperEnabled reports whether the background expiry sweeper runs (EXPIRY_SWEEP_ENABLED)
+func ExpirySweeperEnabled() bool {
+	return envBool("EXPIRY_SWEEP_ENABLED", true)
+}
+
+// LoadExpirySweeperConfig reads background expiry sweeper settings from the environment
+func LoadExpirySweeperConfig() ExpirySweeperConfig {
+	return ExpirySweeperConfig{
+		Interval:   envDuration("EXPIRY_SWEEP_INTERVAL", time.Minute),
+		BatchSize:  envInt("EXPIRY_SWEEP_BATCH_SIZE", 500),
+		MaxBatches: envInt("EXPIRY_SWEEP_MAX_BATCHES", 20),
+		LeaseTTL:   envDuration("EXPIRY_SWEEP_LEASE_TTL", 0),
+	}
+}
+
+// ExpirationPolicy mirrors services.ExpirationPolicy so it can be converted directly.
+type ExpirationPolicy struct {
+	DefaultTTL  time.Duration
+	MaxLifetime time.Duration
+}
+
+// LoadExpirationPolicy reads the default and maximum link lifetimes from the environment
+func LoadExpirationPolicy() ExpirationPolicy {
+	return ExpirationPolicy{
+		DefaultTTL:  envDuration("LINK_DEFAULT_TTL", 24*time.Hour),
+		MaxLifetime: envDuration("LINK_MAX_LIFETIME", 0),
+	}
+}
+
+// RedirectConfig mirrors controllers.RedirectConfig so it can be converted directly.
+type RedirectConfig struct {
+	PreActivation    string
+	PreActivationURL string
+	FallbackURL      string
+	FallbackPage     bool
+}
+
+// LoadRedirectConfig reads how links that can't be followed yet, or anymore, are answered
+func LoadRedirectConfig() RedirectConfig {
+	cfg := RedirectConfig{
+		PreActivation:    envString("PREACTIVATION_RESPONSE", "notfound"),
+		PreActivationURL: os.Getenv("PREACTIVATION_REDIRECT_URL"),
+		FallbackURL:      os.Getenv("FALLBACK_URL"),
+		FallbackPage:     envBool("FALLBACK_PAGE", true),
+	}
+	switch cfg.PreActivation {
+	case "notfound", "page":
+	case "redirect":
+		if cfg.PreActivationURL == "" {
+			logging.Log.Warn("PREACTIVATION_REDIRECT_URL is not set; PREACTIVATION_RESPONSE defaulted to notfound")
+			cfg.PreActivation = "notfound"
+		}
+	default:
+		logging.Log.WithField("value", cfg.PreActivation).Warn("PREACTIVATION_RESPONSE defaulted to notfound")
+		cfg.PreActivation = "notfound"
+	}
+	return cfg
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"net/http"
+	"url-shortener/dto/response"
+	"url-shortener/middleware"
+	"url-shortener/services"
+	"url-shortener/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AnalyticsController struct {
+	analyticsService services.AnalyticsService
+}
+
+func NewAnalyticsController(analyticsService services.AnalyticsService) *AnalyticsController {
+	return &AnalyticsController{analyticsService: analyticsService}
+}
+
+// GetLinkStats returns click analytics for a short link owned by the caller. The optional
+// from/to query parameters accept RFC 3339 timestamps or YYYY-MM-DD dates.
+func (controller *AnalyticsController) GetLinkStats(c *gin.Context) {
+	from, err := utils.ParseTimestamp(c.Query("from"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid 'from' parameter. Use RFC 3339 or YYYY-MM-DD")
+		return
+	}
+	to, err := utils.ParseTimestamp(c.Query("to"))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid 'to' parameter. Use RFC 3339 or YYYY-MM-DD")
+		return
+	}
+
+	stats, err := controller.analyticsService.GetStats(c.Param("shortLink"), from, to, middleware.CurrentAPIKey(c))
+	if err != nil {
+		serviceErrorResponse(c, err, "Failed to load link stats")
+		return
+	}
+
+	c.JSON(http.StatusOK, response.LinkStatsResponse{
+		ShortLink:      stats.ShortLink,
+		TotalClicks:    stats.TotalClicks,
+		From:           stats.From,
+		To:             stats.To,
+		Clicks:         stats.Clicks,
+		UniqueVisitors: stats.UniqueVisitors,
+		Daily:          toTimeSeries(stats.Daily),
+		Hourly:         toTimeSeries(stats.Hourly),
+		TopReferrers:   toCountEntries(stats.TopReferrers),
+		TopUserAgents:  toCountEntries(stats.TopUserAgents),
+		TopCountries:   toCountEntries(stats.TopCountries),
+	})
+}
+
+func toTimeSeries(buckets []services.TimeBucket) []response.TimeSeriesPoint {
+	points := make([]response.TimeSeriesPoint, len(buckets))
+	for i, bucket := range buckets {
+		points[i] = response.TimeSeriesPoint{Start: bucket.Start, Clicks: bucket.Clicks}
+	}
+	return points
+}
+
+func toCountEntries(entries []services.CountEntry) []response.CountEntry {
+	converted := make([]response.CountEntry, len(entries))
+	for i, entry := range entries {
+		converted[i] = response.CountEntry{Value: entry.Value, Count: entry.Count}
+	}
+	return converted
+}
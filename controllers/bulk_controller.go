@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"url-shortener/dto/request"
+	"url-shortener/dto/response"
+	"url-shortener/logging"
+	"url-shortener/middleware"
+	"url-shortener/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type BulkController struct {
+	urlService services.URLService
+	maxItems   int
+}
+
+func NewBulkController(urlService services.URLService, maxItems int) *BulkController {
+	return &BulkController{urlService: urlService, maxItems: maxItems}
+}
+
+// CreateShortURLs shortens a batch of URLs. Every item gets its own result; the response is
+// 201 when all were created, 207 when some failed, and 422 when an atomic batch was rejected.
+func (controller *BulkController) CreateShortURLs(c *gin.Context) {
+	var req request.BulkCreateURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(req.Items) > controller.maxItems {
+		errorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("A batch may contain at most %d items", controller.maxItems))
+		return
+	}
+
+	// Request-level validation happens per item, so only the valid ones reach the service
+	owner := middleware.CurrentAPIKey(c)
+	outcomes := make([]response.BulkItemOutcome, len(req.Items))
+	params := make([]services.CreateURLParams, 0, len(req.Items))
+	indexes := make([]int, 0, len(req.Items))
+	for i, item := range req.Items {
+		outcomes[i].Index = i
+		if err := binding.Validator.ValidateStruct(item); err != nil {
+			outcomes[i].Status, outcomes[i].Error = http.StatusBadRequest, err.Error()
+			continue
+		}
+		itemParams, err := createURLParams(item, owner)
+		if err != nil {
+			outcomes[i].Status, outcomes[i].Error = http.StatusBadRequest, err.Error()
+			continue
+		}
+		params = append(params, itemParams)
+		indexes = append(indexes, i)
+	}
+
+	if !req.Atomic || len(params) == len(req.Items) {
+		results, err := controller.urlService.CreateURLs(params, req.Atomic)
+		if err != nil && !errors.Is(err, services.ErrBatchRejected) {
+			serviceErrorResponse(c, err, "Failed to create short URLs")
+			return
+		}
+		for j, result := range results {
+			outcome := &outcomes[indexes[j]]
+			switch {
+			case result.Err != nil:
+				outcome.Status, outcome.Error = serviceErrorStatus(result.Err)
+				if outcome.Status == http.StatusInternalServerError {
+					logging.Log.WithError(result.Err).WithField("index", indexes[j]).Error("Failed to create short URL in batch")
+				}
+			case result.URL != nil:
+				link := toURLResponse(result.URL)
+				outcome.Status, outcome.Link = http.StatusCreated, &link
+			}
+		}
+	}
+
+	resp := response.BulkCreateResponse{Results: outcomes}
+	for i := range outcomes {
+		switch outcomes[i].Status {
+		case http.StatusCreated:
+			resp.Created++
+		case 0:
+			// Valid, but not stored because another item rejected the atomic batch
+			outcomes[i].Status, outcomes[i].Error = http.StatusFailedDependency, "Not created because another item in the batch failed"
+			resp.Failed++
+		default:
+			resp.Failed++
+		}
+	}
+
+	switch {
+	case resp.Failed == 0:
+		c.JSON(http.StatusCreated, resp)
+	case req.Atomic:
+		c.JSON(http.StatusUnprocessableEntity, resp)
+	default:
+		c.JSON(http.StatusMultiStatus, resp)
+	}
+}
@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"net/http"
+	"url-shortener/middleware"
+	"url-shortener/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LifecycleController struct {
+	lifecycleService services.LinkLifecycleService
+}
+
+func NewLifecycleController(lifecycleService services.LinkLifecycleService) *LifecycleController {
+	return &LifecycleController{lifecycleService: lifecycleService}
+}
+
+// RestoreURL undeletes a link owned by the caller while its tombstone period lasts
+func (controller *LifecycleController) RestoreURL(c *gin.Context) {
+	url, err := controller.lifecycleService.RestoreURL(c.Param("shortLink"), middleware.CurrentAPIKey(c))
+	if err != nil {
+		serviceErrorResponse(c, err, "Failed to restore URL")
+		return
+	}
+
+	c.JSON(http.StatusOK, toURLResponse(url))
+}
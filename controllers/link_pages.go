@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+	"url-shortener/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// What RedirectToURL responds with before a link's activation time.
+const (
+	PreActivationNotFound = "notfound" // 404, as if the link didn't exist yet
+	PreActivationPage     = "page"     // A "coming soon" page naming the launch time
+	PreActivationRedirect = "redirect" // Redirect to RedirectConfig.PreActivationURL
+)
+
+// RedirectConfig configures the responses of RedirectToURL for links that can't be followed.
+type RedirectConfig struct {
+	PreActivation    string // One of the PreActivation* modes; "" for PreActivationNotFound
+	PreActivationURL string // Destination for PreActivationRedirect
+
+	// FallbackURL receives visitors of expired and deleted links that have no fallback of
+	// their own. Without one, browsers get an HTML page if FallbackPage is set, and everyone
+	// else a JSON error.
+	FallbackURL  string
+	FallbackPage bool
+}
+
+var comingSoonPage = template.Must(template.New("coming-soon").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Coming soon</title>
+</head>
+<body>
+<h1>Coming soon</h1>
+<p>This link goes live on <time datetime="{{.ISO}}">{{.Human}}</time>.</p>
+</body>
+</html>
+`))
+
+var unavailablePage = template.Must(template.New("unavailable").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Link unavailable</title>
+</head>
+<body>
+<h1>This link is no longer available</h1>
+<p>{{.}}</p>
+</body>
+</html>
+`))
+
+// respondNotActive answers a request for a link before activatesAt. The response must not be
+// cached, since it changes once the link is live.
+func (config RedirectConfig) respondNotActive(c *gin.Context, activatesAt time.Time) {
+	c.Header("Cache-Control", "no-store")
+	switch config.PreActivation {
+	case PreActivationPage:
+		c.Status(http.StatusOK)
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		activatesAt = activatesAt.UTC()
+		comingSoonPage.Execute(c.Writer, struct{ ISO, Human string }{
+			ISO:   activatesAt.Format(time.RFC3339),
+			Human: activatesAt.Format("January 2, 2006 at 15:04 MST"),
+		})
+	case PreActivationRedirect:
+		c.Redirect(http.StatusFound, config.PreActivationURL)
+	default:
+		errorResponse(c, http.StatusNotFound, "Short URL not found")
+	}
+}
+
+// respondUnavailable answers a request for an expired or deleted link with the link's own
+// fallback, the global one, a page for browsers or a JSON error, in that order of preference.
+func (config RedirectConfig) respondUnavailable(c *gin.Context, err *services.UnavailableError) {
+	status, message := serviceErrorStatus(err)
+	switch {
+	case err.FallbackURL != "":
+		c.Redirect(http.StatusFound, err.FallbackURL)
+	case config.FallbackURL != "":
+		c.Redirect(http.StatusFound, config.FallbackURL)
+	case config.FallbackPage && c.NegotiateFormat(binding.MIMEJSON, binding.MIMEHTML) == binding.MIMEHTML:
+		c.Status(status)
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		unavailablePage.Execute(c.Writer, message)
+	default:
+		errorResponse(c, status, message)
+	}
+}
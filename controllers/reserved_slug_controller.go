@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"url-shortener/dto/request"
+	"url-shortener/dto/response"
+	"url-shortener/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReservedSlugController struct {
+	reservedSlugService services.ReservedSlugService
+}
+
+func NewReservedSlugController(reservedSlugService services.ReservedSlugService) *ReservedSlugController {
+	return &ReservedSlugController{reservedSlugService: reservedSlugService}
+}
+
+// ListReservedSlugs returns every reserved word and pattern with where it comes from
+func (controller *ReservedSlugController) ListReservedSlugs(c *gin.Context) {
+	entries, err := controller.reservedSlugService.List()
+	if err != nil {
+		serviceErrorResponse(c, err, "Failed to list reserved slugs")
+		return
+	}
+	resp := response.ReservedSlugListResponse{ReservedSlugs: make([]response.ReservedSlugResponse, len(entries))}
+	for i, entry := range entries {
+		resp.ReservedSlugs[i] = toReservedSlugResponse(entry)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// AddReservedSlug reserves a word or pattern. Existing links that match keep working.
+func (controller *ReservedSlugController) AddReservedSlug(c *gin.Context) {
+	var req request.AddReservedSlugRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	entry, err := controller.reservedSlugService.Add(req.Value, req.Pattern, req.Note)
+	if err != nil {
+		serviceErrorResponse(c, err, "Failed to reserve slug")
+		return
+	}
+	c.JSON(http.StatusCreated, toReservedSlugResponse(*entry))
+}
+
+// RemoveReservedSlug deletes a reserved slug added through the API
+func (controller *ReservedSlugController) RemoveReservedSlug(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid reserved slug ID")
+		return
+	}
+	if err := controller.reservedSlugService.Remove(uint(id)); err != nil {
+		serviceErrorResponse(c, err, "Failed to remove reserved slug")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Reserved slug removed successfully"})
+}
+
+func toReservedSlugResponse(entry services.ReservedSlugEntry) response.ReservedSlugResponse {
+	resp := response.ReservedSlugResponse{
+		ID:      entry.ID,
+		Value:   entry.Value,
+		Pattern: entry.Pattern,
+		Note:    entry.Note,
+		Source:  entry.Source,
+	}
+	if !entry.CreatedAt.IsZero() {
+		createdAt := entry.CreatedAt
+		resp.CreatedAt = &createdAt
+	}
+	return resp
+}
@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"url-shortener/dto/request"
+	"url-shortener/dto/response"
+	"url-shortener/logging"
+	"url-shortener/middleware"
+	"url-shortener/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// transferContentTypes maps each transfer format to the media type it is served as.
+var transferContentTypes = map[services.TransferFormat]string{
+	services.FormatCSV:   "text/csv; charset=utf-8",
+	services.FormatJSONL: "application/x-ndjson",
+}
+
+type TransferController struct {
+	transferService services.LinkTransferService
+	maxImportBytes  int64
+}
+
+func NewTransferController(transferService services.LinkTransferService, maxImportBytes int64) *TransferController {
+	return &TransferController{transferService: transferService, maxImportBytes: maxImportBytes}
+}
+
+// ExportLinks streams the caller's links (every link for admins) as JSON Lines or CSV,
+// filtered like ListURLs
+func (controller *TransferController) ExportLinks(c *gin.Context) {
+	var req request.ExportLinksRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter, err := linkFilter(req.LinkFilterRequest)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	format := services.FormatJSONL
+	if req.Format != "" {
+		format = services.TransferFormat(req.Format)
+	}
+
+	w := &downloadWriter{
+		c:           c,
+		contentType: transferContentTypes[format],
+		filename:    fmt.Sprintf("links-%s.%s", time.Now().UTC().Format("20060102"), format),
+	}
+	if err := controller.transferService.Export(w, format, filter, middleware.CurrentAPIKey(c)); err != nil {
+		if w.started {
+			// Too late for an error status; the truncated download is all we can signal
+			logging.Log.WithError(err).Error("Link export failed mid-stream")
+			c.Abort()
+			return
+		}
+		serviceErrorResponse(c, err, "Failed to export links")
+	}
+}
+
+// ImportLinks creates links from a CSV or JSON Lines request body and reports on every row
+// that wasn't simply created. With dryRun=true nothing is written.
+func (controller *TransferController) ImportLinks(c *gin.Context) {
+	var req request.ImportLinksRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	format := services.TransferFormat(req.Format)
+	if format == "" {
+		format = services.FormatJSONL
+		if strings.Contains(c.ContentType(), "csv") {
+			format = services.FormatCSV
+		}
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, controller.maxImportBytes)
+	report, err := controller.transferService.Import(body, services.ImportOptions{
+		Format:   format,
+		Conflict: services.ConflictPolicy(req.Conflict),
+		DryRun:   req.DryRun,
+	}, middleware.CurrentAPIKey(c))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		errorResponse(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("Import files may be at most %d bytes", tooLarge.Limit))
+		return
+	}
+	if err != nil {
+		serviceErrorResponse(c, err, "Failed to import links")
+		return
+	}
+
+	resp := response.ImportReportResponse{
+		DryRun:      report.DryRun,
+		Created:     report.Created,
+		Overwritten: report.Overwritten,
+		Renamed:     report.Renamed,
+		Skipped:     report.Skipped,
+		Failed:      report.Failed,
+		Rows:        make([]response.ImportRowEntry, len(report.Rows)),
+	}
+	for i, row := range report.Rows {
+		resp.Rows[i] = response.ImportRowEntry{
+			Line:        row.Line,
+			ShortLink:   row.ShortLink,
+			Action:      string(row.Action),
+			RenamedFrom: row.RenamedFrom,
+			Error:       row.Error,
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// downloadWriter sets the download headers on the first write, so errors raised before any
+// output can still be reported as JSON.
+type downloadWriter struct {
+	c           *gin.Context
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (w *downloadWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.started = true
+		w.c.Header("Content-Type", w.contentType)
+		w.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", w.filename))
+		w.c.Status(http.StatusOK)
+	}
+	return w.c.Writer.Write(p)
+}
@@ -1,18 +1,20 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
-	"strings"
 	"time"
 	"url-shortener/dto/request"
 	"url-shortener/dto/response"
 	"url-shortener/logging" // Added for logrus
+	"url-shortener/middleware"
 	"url-shortener/models"
+	"url-shortener/services"
 	"url-shortener/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus" // Added for logrus fields
-	"gorm.io/gorm"
 )
 
 // Helper function for standardized error responses
@@ -34,12 +36,74 @@ func internalServerErrorResponse(c *gin.Context, err error, message string) {
 	c.JSON(http.StatusInternalServerError, gin.H{"error": "An internal server error occurred"}) // Generic message to client
 }
 
+// Helper function mapping typed service errors to HTTP statuses; anything unrecognised is a 500
+func serviceErrorResponse(c *gin.Context, err error, message string) {
+	status, clientMessage := serviceErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		internalServerErrorResponse(c, err, message)
+		return
+	}
+	errorResponse(c, status, clientMessage)
+}
+
+// serviceErrorStatus returns the HTTP status and client-facing message for a service error
+func serviceErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, services.ErrURLNotFound), errors.Is(err, services.ErrNotYetActive):
+		return http.StatusNotFound, "Short URL not found"
+	case errors.Is(err, services.ErrSlugTaken):
+		return http.StatusConflict, "Custom slug already exists"
+	case errors.Is(err, services.ErrSlugSpaceExhausted):
+		return http.StatusServiceUnavailable, "No free short link could be found; try again or use a custom slug"
+	case errors.Is(err, services.ErrSlugReserved):
+		return http.StatusConflict, "Custom slug is reserved"
+	case errors.Is(err, services.ErrSlugInvalid):
+		return http.StatusBadRequest, "Custom slug must be 3-8 alphanumeric characters"
+	case errors.Is(err, services.ErrUnknownSlugStrategy):
+		return http.StatusBadRequest, "Slug strategy must be random, sequential or words"
+	case errors.Is(err, services.ErrURLExpired):
+		return http.StatusGone, "URL has expired"
+	case errors.Is(err, services.ErrURLDeleted):
+		return http.StatusGone, "URL has been deleted"
+	case errors.Is(err, services.ErrNotDeleted):
+		return http.StatusConflict, "Short URL is not deleted"
+	case errors.Is(err, services.ErrRestoreExpired):
+		return http.StatusGone, "Short URL was deleted or expired too long ago to restore"
+	case errors.Is(err, services.ErrInvalidURL):
+		return http.StatusBadRequest, "URL must start with http:// or https://"
+	case errors.Is(err, services.ErrForbidden):
+		return http.StatusForbidden, "Only the link's owner or an admin may modify it"
+	case errors.Is(err, services.ErrNothingToUpdate):
+		return http.StatusBadRequest, "Request contains no fields to update"
+	case errors.Is(err, services.ErrInvalidExpiration):
+		return http.StatusBadRequest, "Expiration date must be in the future"
+	case errors.Is(err, services.ErrInvalidActivation):
+		return http.StatusBadRequest, "Activation time must be before the expiration date"
+	case errors.Is(err, services.ErrInvalidStatsRange):
+		return http.StatusBadRequest, "Invalid range: 'from' must be before 'to' and span at most 90 days"
+	case errors.Is(err, services.ErrReservedSlugNotFound):
+		return http.StatusNotFound, "Reserved slug not found"
+	case errors.Is(err, services.ErrReservedSlugExists):
+		return http.StatusConflict, "Slug is reserved already"
+	case errors.Is(err, services.ErrInvalidListQuery), errors.Is(err, services.ErrInvalidTransfer), errors.Is(err, services.ErrInvalidReservedSlug),
+		errors.Is(err, services.ErrExpirationTooLate):
+		return http.StatusBadRequest, err.Error()
+	default:
+		return http.StatusInternalServerError, "An internal server error occurred"
+	}
+}
+
+// countryHeaders are set by common CDNs and load balancers with the client's country code.
+var countryHeaders = []string{"CF-IPCountry", "CloudFront-Viewer-Country", "X-AppEngine-Country", "X-Country-Code"}
+
 type URLController struct {
-	db *gorm.DB
+	urlService       services.URLService
+	analyticsService services.AnalyticsService
+	redirects        RedirectConfig
 }
 
-func NewURLController(db *gorm.DB) *URLController {
-	return &URLController{db: db}
+func NewURLController(urlService services.URLService, analyticsService services.AnalyticsService, redirects RedirectConfig) *URLController {
+	return &URLController{urlService: urlService, analyticsService: analyticsService, redirects: redirects}
 }
 
 func (controller *URLController) CreateShortURL(c *gin.Context) {
@@ -49,113 +113,254 @@ func (controller *URLController) CreateShortURL(c *gin.Context) {
 		return
 	}
 
-	if !strings.HasPrefix(req.URL, "http://") && !strings.HasPrefix(req.URL, "https://") {
-		errorResponse(c, http.StatusBadRequest, "URL must start with http:// or https://")
+	params, err := createURLParams(req, middleware.CurrentAPIKey(c))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	expirationDate := time.Now().Add(24 * time.Hour) // Default expiration: 24 hours
-	if req.ExpirationDate != "" {
-		parsedDate, err := time.Parse("2006-01-02", req.ExpirationDate)
+	url, err := controller.urlService.CreateURL(params)
+	if err != nil {
+		serviceErrorResponse(c, err, "Failed to create short URL")
+		return
+	}
+
+	c.JSON(http.StatusCreated, toURLResponse(url))
+}
+
+// createURLParams converts a create request into service params; errors are client-facing
+func createURLParams(req request.CreateURLRequest, owner *models.APIKey) (services.CreateURLParams, error) {
+	params := services.CreateURLParams{
+		OriginalURL:  req.URL,
+		CustomSlug:   req.CustomSlug,
+		Owner:        owner,
+		SlugStrategy: req.SlugStrategy,
+		FallbackURL:  req.FallbackURL,
+	}
+	// A zero ExpirationDate lets the service apply its default
+	expirationDate, err := requestedExpiration(req.ExpirationDate, req.TTL, req.NeverExpires)
+	if err != nil {
+		return params, err
+	}
+	params.ExpirationDate = expirationDate
+	params.ActivatesAt, err = requestedActivation(req.ActivatesAt)
+	return params, err
+}
+
+// requestedActivation parses an activation time, zero if none was set. Errors are client-facing.
+func requestedActivation(activatesAt string) (time.Time, error) {
+	if activatesAt == "" {
+		return time.Time{}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, activatesAt)
+	if err != nil {
+		return time.Time{}, errors.New("Invalid activatesAt format. Use RFC 3339")
+	}
+	return parsed, nil
+}
+
+// requestedExpiration turns the expiration fields of a request into a date, zero if none
+// was set. Errors are client-facing.
+func requestedExpiration(expirationDate, ttl string, neverExpires bool) (time.Time, error) {
+	switch {
+	case neverExpires:
+		return models.NeverExpires, nil
+	case ttl != "":
+		duration, err := time.ParseDuration(ttl)
+		if err != nil || duration <= 0 {
+			return time.Time{}, errors.New("Invalid ttl. Use a positive duration such as 90m or 72h")
+		}
+		return time.Now().Add(duration), nil
+	default:
+		parsedDate, err := utils.ParseTimestamp(expirationDate)
 		if err != nil {
-			errorResponse(c, http.StatusBadRequest, "Invalid expiration date format. Use YYYY-MM-DD")
-			return
+			return time.Time{}, errors.New("Invalid expiration date format. Use RFC 3339 or YYYY-MM-DD")
 		}
-		expirationDate = parsedDate
+		return parsedDate, nil
 	}
+}
 
-	var shortLink string
-	if req.CustomSlug != "" {
-		var existingURL models.URL
-		// Check if custom slug already exists
-		if result := controller.db.Where("short_link = ?", req.CustomSlug).First(&existingURL); result.Error == nil {
-			errorResponse(c, http.StatusConflict, "Custom slug already exists")
-			return
-		} else if result.Error != gorm.ErrRecordNotFound {
-			internalServerErrorResponse(c, result.Error, "Database error checking custom slug")
+// UpdateURL changes the destination, expiration, activation and/or fallback of a link owned by the caller
+func (controller *URLController) UpdateURL(c *gin.Context) {
+	var req request.UpdateURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := services.UpdateURLParams{OriginalURL: req.URL, FallbackURL: req.FallbackURL}
+	if req.ExpirationDate != nil || req.TTL != "" || req.NeverExpires {
+		var expirationDate string
+		if req.ExpirationDate != nil {
+			expirationDate = *req.ExpirationDate
+		}
+		parsedDate, err := requestedExpiration(expirationDate, req.TTL, req.NeverExpires)
+		if err != nil {
+			errorResponse(c, http.StatusBadRequest, err.Error())
 			return
 		}
-		shortLink = req.CustomSlug
-	} else {
-		// Generate a unique random slug
-		for {
-			shortLink = utils.GenerateRandomSlug(6)
-			var existingURL models.URL
-			if result := controller.db.Where("short_link = ?", shortLink).First(&existingURL); result.Error != nil {
-				if result.Error == gorm.ErrRecordNotFound {
-					// If error (record not found), slug is unique
-					break
-				}
-				internalServerErrorResponse(c, result.Error, "Database error generating unique slug")
-				return
-			}
+		params.ExpirationDate = &parsedDate
+	}
+	if req.ActivatesAt != nil {
+		activatesAt, err := requestedActivation(*req.ActivatesAt)
+		if err != nil {
+			errorResponse(c, http.StatusBadRequest, err.Error())
+			return
 		}
+		params.ActivatesAt = &activatesAt
 	}
 
-	url := models.URL{
-		OriginalURL:    req.URL,
-		ShortLink:      shortLink,
-		ExpirationDate: expirationDate,
+	url, err := controller.urlService.UpdateURL(c.Param("shortLink"), params, middleware.CurrentAPIKey(c))
+	if err != nil {
+		serviceErrorResponse(c, err, "Failed to update URL")
+		return
 	}
 
-	result := controller.db.Create(&url)
-	if result.Error != nil {
-		internalServerErrorResponse(c, result.Error, "Failed to create short URL")
+	c.JSON(http.StatusOK, toURLResponse(url))
+}
+
+// ListURLs pages through the caller's links (every link for admins) with optional filters
+func (controller *URLController) ListURLs(c *gin.Context) {
+	var req request.ListURLsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusCreated, response.URLResponse{
+	filter, err := linkFilter(req.LinkFilterRequest)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := controller.urlService.ListURLs(services.ListURLsParams{
+		LinkFilter: filter,
+		Sort:       req.Sort,
+		Cursor:     req.Cursor,
+		Limit:      req.Limit,
+	}, middleware.CurrentAPIKey(c))
+	if err != nil {
+		serviceErrorResponse(c, err, "Failed to list URLs")
+		return
+	}
+
+	links := make([]response.URLResponse, len(page.URLs))
+	for i := range page.URLs {
+		links[i] = toURLResponse(&page.URLs[i])
+	}
+	c.JSON(http.StatusOK, response.LinkListResponse{Links: links, NextCursor: page.NextCursor})
+}
+
+// linkFilter converts filter query parameters into service filters; errors are client-facing
+func linkFilter(req request.LinkFilterRequest) (services.LinkFilter, error) {
+	createdAfter, err := utils.ParseTimestamp(req.CreatedAfter)
+	if err != nil {
+		return services.LinkFilter{}, errors.New("Invalid 'createdAfter' parameter. Use RFC 3339 or YYYY-MM-DD")
+	}
+	createdBefore, err := utils.ParseTimestamp(req.CreatedBefore)
+	if err != nil {
+		return services.LinkFilter{}, errors.New("Invalid 'createdBefore' parameter. Use RFC 3339 or YYYY-MM-DD")
+	}
+	return services.LinkFilter{
+		CreatedAfter:  createdAfter,
+		CreatedBefore: createdBefore,
+		Status:        req.Status,
+		Domain:        req.Domain,
+		SlugPrefix:    req.SlugPrefix,
+		Search:        req.Search,
+	}, nil
+}
+
+// slugReasonMessages are the human-readable explanations returned with each SlugReason
+var slugReasonMessages = map[services.SlugReason]string{
+	services.SlugTaken:          "This slug is already in use",
+	services.SlugReserved:       "This slug is reserved",
+	services.SlugInvalidCharset: "Slugs may only contain letters and digits",
+	services.SlugTooShort:       fmt.Sprintf("Slugs must be at least %d characters", services.MinSlugLength),
+	services.SlugTooLong:        fmt.Sprintf("Slugs must be at most %d characters", services.MaxSlugLength),
+}
+
+// CheckSlugAvailability reports whether a custom slug can be claimed, e.g. for validating
+// a vanity slug as the user types
+func (controller *URLController) CheckSlugAvailability(c *gin.Context) {
+	var req request.ValidateSlugRequest
+	if err := c.ShouldBind(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	availability, err := controller.urlService.CheckSlugAvailability(req.CustomSlug)
+	if err != nil {
+		serviceErrorResponse(c, err, "Failed to check slug availability")
+		return
+	}
+
+	c.JSON(http.StatusOK, response.SlugAvailabilityResponse{
+		Slug:        availability.Slug,
+		Available:   availability.Available,
+		Reason:      string(availability.Reason),
+		Message:     slugReasonMessages[availability.Reason],
+		Suggestions: availability.Suggestions,
+	})
+}
+
+func toURLResponse(url *models.URL) response.URLResponse {
+	return response.URLResponse{
 		OriginalURL:    url.OriginalURL,
 		ShortLink:      url.ShortLink,
 		ExpirationDate: url.ExpirationDate,
-	})
+		NeverExpires:   !url.Expires(),
+		ActivatesAt:    url.ActivatesAt,
+		FallbackURL:    url.FallbackURL,
+		CreatedAt:      url.CreatedAt,
+	}
 }
 
 func (controller *URLController) RedirectToURL(c *gin.Context) {
-	shortLink := c.Param("shortLink")
-	var url models.URL
-
-	if result := controller.db.Where("short_link = ?", shortLink).First(&url); result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			errorResponse(c, http.StatusNotFound, "Short URL not found")
-		} else {
-			internalServerErrorResponse(c, result.Error, "Database error fetching URL for redirect")
-		}
+	url, err := controller.urlService.GetURL(c.Param("shortLink"))
+	var notActive *services.NotActiveError
+	if errors.As(err, &notActive) {
+		controller.redirects.respondNotActive(c, notActive.ActivatesAt)
 		return
 	}
-
-	// Check if the URL has expired
-	if time.Now().After(url.ExpirationDate) {
-		if delResult := controller.db.Delete(&url); delResult.Error != nil {
-			internalServerErrorResponse(c, delResult.Error, "Failed to delete expired URL")
-			// Even if deletion fails, the URL is still expired from client's perspective
-			// So, we still return StatusGone.
-			// Alternatively, one might choose to not delete and let a background job handle it.
-		}
-		errorResponse(c, http.StatusGone, "URL has expired")
+	var unavailable *services.UnavailableError
+	if errors.As(err, &unavailable) {
+		controller.redirects.respondUnavailable(c, unavailable)
+		return
+	}
+	if err != nil {
+		serviceErrorResponse(c, err, "Failed to fetch URL for redirect")
 		return
 	}
 
+	if err := controller.analyticsService.RecordClick(url, visitFromRequest(c)); err != nil {
+		// Analytics must never break the redirect itself
+		logging.Log.WithError(err).WithField("short_link", url.ShortLink).Error("Failed to record click")
+	}
+
 	c.Redirect(http.StatusFound, url.OriginalURL)
 }
 
-func (controller *URLController) DeleteShortURL(c *gin.Context) {
-	shortLink := c.Param("shortLink")
-	var url models.URL
-
-	// Find the URL by shortLink
-	if result := controller.db.Where("short_link = ?", shortLink).First(&url); result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			errorResponse(c, http.StatusNotFound, "Short URL not found")
-		} else {
-			internalServerErrorResponse(c, result.Error, "Database error fetching URL for deletion")
+// visitFromRequest captures the request details recorded for a click
+func visitFromRequest(c *gin.Context) services.Visit {
+	visit := services.Visit{
+		Time:      time.Now(),
+		Referrer:  c.Request.Referer(),
+		UserAgent: c.Request.UserAgent(),
+		ClientIP:  middleware.ClientIP(c),
+	}
+	for _, header := range countryHeaders {
+		if country := c.GetHeader(header); country != "" {
+			visit.Country = country
+			break
 		}
-		return
 	}
+	return visit
+}
 
-	// Delete the URL
-	if result := controller.db.Delete(&url); result.Error != nil {
-		internalServerErrorResponse(c, result.Error, "Failed to delete URL")
+func (controller *URLController) DeleteShortURL(c *gin.Context) {
+	if err := controller.urlService.DeleteURL(c.Param("shortLink"), middleware.CurrentAPIKey(c)); err != nil {
+		serviceErrorResponse(c, err, "Failed to delete URL")
 		return
 	}
 
@@ -163,17 +368,7 @@ func (controller *URLController) DeleteShortURL(c *gin.Context) {
 }
 
 func (controller *URLController) Ping(c *gin.Context) {
-	sqlDB, err := controller.db.DB() // controller is the URLController instance
-	if err != nil {
-		// Log the error internally
-		logging.Log.WithError(err).Error("Failed to get underlying DB object for health check")
-		// Use the standardized error response
-		internalServerErrorResponse(c, err, "Error accessing database for health check") // Use internalServerErrorResponse for 500
-		return
-	}
-
-	err = sqlDB.Ping()
-	if err != nil {
+	if err := controller.urlService.Ping(); err != nil {
 		// Log the error internally
 		logging.Log.WithError(err).Warn("Database ping failed during health check")
 		// Use the standardized error response, but with 503
@@ -0,0 +1,651 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"url-shortener/dto/response"
+	"url-shortener/middleware"
+	"url-shortener/models"
+	"url-shortener/repositories"
+	"url-shortener/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeURLService is an in-memory URLService stand-in for handler tests.
+type fakeURLService struct {
+	urls    map[string]*models.URL
+	err     error // returned by every method when set
+	pingErr error
+
+	listParams services.ListURLsParams // last ListURLs call
+	listPage   services.URLPage
+}
+
+func newFakeURLService() *fakeURLService {
+	return &fakeURLService{urls: make(map[string]*models.URL)}
+}
+
+func (f *fakeURLService) CreateURL(params services.CreateURLParams) (*models.URL, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	customSlug := params.CustomSlug
+	if _, ok := f.urls[customSlug]; ok {
+		return nil, services.ErrSlugTaken
+	}
+	if customSlug == "" {
+		customSlug = "abc123"
+	}
+	url := &models.URL{OriginalURL: params.OriginalURL, ShortLink: customSlug, ExpirationDate: params.ExpirationDate}
+	if params.Owner != nil {
+		url.OwnerID = &params.Owner.ID
+	}
+	f.urls[customSlug] = url
+	return url, nil
+}
+
+func (f *fakeURLService) CreateURLs(items []services.CreateURLParams, atomic bool) ([]services.BulkResult, error) {
+	results := make([]services.BulkResult, len(items))
+	for i, params := range items {
+		results[i].URL, results[i].Err = f.CreateURL(params)
+	}
+	return results, nil
+}
+
+func (f *fakeURLService) GetURL(shortLink string) (*models.URL, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	url, ok := f.urls[shortLink]
+	if !ok {
+		return nil, services.ErrURLNotFound
+	}
+	return url, nil
+}
+
+func (f *fakeURLService) DeleteURL(shortLink string, actor *models.APIKey) error {
+	url, err := f.GetURL(shortLink)
+	if err != nil {
+		return err
+	}
+	if !services.CanModify(actor, url) {
+		return services.ErrForbidden
+	}
+	delete(f.urls, shortLink)
+	return nil
+}
+
+func (f *fakeURLService) IsCustomSlugExists(customSlug string) (bool, error) {
+	_, ok := f.urls[customSlug]
+	return ok, f.err
+}
+
+func (f *fakeURLService) CheckSlugAvailability(customSlug string) (*services.SlugAvailability, error) {
+	if _, ok := f.urls[customSlug]; ok {
+		return &services.SlugAvailability{Slug: customSlug, Reason: services.SlugTaken, Suggestions: []string{customSlug + "1"}}, nil
+	}
+	return &services.SlugAvailability{Slug: customSlug, Available: true}, f.err
+}
+
+func (f *fakeURLService) UpdateURL(shortLink string, params services.UpdateURLParams, actor *models.APIKey) (*models.URL, error) {
+	url, err := f.GetURL(shortLink)
+	if err != nil {
+		return nil, err
+	}
+	if !services.CanModify(actor, url) {
+		return nil, services.ErrForbidden
+	}
+	if params.OriginalURL != nil {
+		url.OriginalURL = *params.OriginalURL
+	}
+	if params.ExpirationDate != nil {
+		url.ExpirationDate = *params.ExpirationDate
+	}
+	return url, nil
+}
+
+func (f *fakeURLService) ListURLs(params services.ListURLsParams, actor *models.APIKey) (*services.URLPage, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.listParams = params
+	return &f.listPage, nil
+}
+
+func (f *fakeURLService) Ping() error {
+	return f.pingErr
+}
+
+// fakeAnalyticsService records visits without aggregating them.
+type fakeAnalyticsService struct {
+	visits []services.Visit
+}
+
+func (f *fakeAnalyticsService) RecordClick(url *models.URL, visit services.Visit) error {
+	f.visits = append(f.visits, visit)
+	return nil
+}
+
+func (f *fakeAnalyticsService) GetStats(shortLink string, from, to time.Time, actor *models.APIKey) (*services.LinkStats, error) {
+	return &services.LinkStats{ShortLink: shortLink, From: from, To: to}, nil
+}
+
+// fakeAuthenticator accepts the plaintext keys in its map.
+type fakeAuthenticator map[string]*models.APIKey
+
+func (f fakeAuthenticator) Authenticate(plaintext string) (*models.APIKey, error) {
+	if key, ok := f[plaintext]; ok {
+		return key, nil
+	}
+	return nil, services.ErrInvalidAPIKey
+}
+
+var testKeys = fakeAuthenticator{
+	"admin": {ID: 1, Name: "admin", IsAdmin: true},
+	"alice": {ID: 2, Name: "alice"},
+	"bob":   {ID: 3, Name: "bob"},
+}
+
+func newTestRouter(svc services.URLService) *gin.Engine {
+	return newTestRouterWithAnalytics(svc, &fakeAnalyticsService{})
+}
+
+func newTestRouterWithAnalytics(svc services.URLService, analytics services.AnalyticsService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.APIKeyAuth(testKeys, false))
+	controller := NewURLController(svc, analytics, RedirectConfig{})
+	router.GET("/ping", controller.Ping)
+	router.POST("/generate/shortlink", controller.CreateShortURL)
+	router.GET("/:shortLink", controller.RedirectToURL)
+	router.DELETE("/:shortLink", controller.DeleteShortURL)
+	router.GET("/api/links", controller.ListURLs)
+	router.POST("/api/links/bulk", NewBulkController(svc, 3).CreateShortURLs)
+	router.PATCH("/api/links/:shortLink", controller.UpdateURL)
+	router.GET("/api/slugs/availability", controller.CheckSlugAvailability)
+	return router
+}
+
+func performRequest(router *gin.Engine, method, path string, body interface{}) *httptest.ResponseRecorder {
+	return performRequestWithKey(router, method, path, body, "")
+}
+
+func performRequestWithKey(router *gin.Engine, method, path string, body interface{}, apiKey string) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req, _ := http.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateShortURLHandler(t *testing.T) {
+	svc := newFakeURLService()
+	router := newTestRouter(svc)
+
+	w := performRequest(router, "POST", "/generate/shortlink", gin.H{"url": "https://example.com", "customSlug": "exmpl"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, svc.urls, "exmpl")
+
+	w = performRequest(router, "POST", "/generate/shortlink", gin.H{"url": "https://example.com", "customSlug": "exmpl"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = performRequest(router, "POST", "/generate/shortlink", gin.H{"url": "https://example.com", "expirationDate": "31-12-2030"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Expiration as a timestamp, a TTL or never, but only one of them
+	w = performRequest(router, "POST", "/generate/shortlink", gin.H{"url": "https://example.com", "customSlug": "stamp", "expirationDate": "2030-12-31T18:30:00+02:00"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.True(t, svc.urls["stamp"].ExpirationDate.Equal(time.Date(2030, 12, 31, 16, 30, 0, 0, time.UTC)))
+	w = performRequest(router, "POST", "/generate/shortlink", gin.H{"url": "https://example.com", "customSlug": "ttl", "ttl": "72h"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.WithinDuration(t, time.Now().Add(72*time.Hour), svc.urls["ttl"].ExpirationDate, time.Minute)
+	w = performRequest(router, "POST", "/generate/shortlink", gin.H{"url": "https://example.com", "customSlug": "forever", "neverExpires": true})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created response.URLResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.True(t, created.NeverExpires)
+	w = performRequest(router, "POST", "/generate/shortlink", gin.H{"url": "https://example.com", "ttl": "-1h"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = performRequest(router, "POST", "/generate/shortlink", gin.H{"url": "https://example.com", "ttl": "1h", "neverExpires": true})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// A slug strategy only applies to generated slugs
+	w = performRequest(router, "POST", "/generate/shortlink", gin.H{"url": "https://example.com", "slugStrategy": "emoji"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = performRequest(router, "POST", "/generate/shortlink", gin.H{"url": "https://example.com", "customSlug": "other", "slugStrategy": "words"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestBulkCreateHandler(t *testing.T) {
+	svc := newFakeURLService()
+	svc.urls["taken"] = &models.URL{ShortLink: "taken"}
+	router := newTestRouter(svc)
+
+	w := performRequestWithKey(router, "POST", "/api/links/bulk", gin.H{"items": []gin.H{
+		{"url": "https://example.com/a", "customSlug": "first"},
+		{"url": "not a url"},
+		{"url": "https://example.com/c", "customSlug": "taken"},
+	}}, "alice")
+	assert.Equal(t, http.StatusMultiStatus, w.Code)
+	var resp response.BulkCreateResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 1, resp.Created)
+	assert.Equal(t, 2, resp.Failed)
+	if assert.Len(t, resp.Results, 3) {
+		assert.Equal(t, http.StatusCreated, resp.Results[0].Status)
+		assert.Equal(t, "first", resp.Results[0].Link.ShortLink)
+		assert.Equal(t, http.StatusBadRequest, resp.Results[1].Status)
+		assert.Equal(t, 2, resp.Results[2].Index)
+		assert.Equal(t, http.StatusConflict, resp.Results[2].Status)
+		assert.Equal(t, "Custom slug already exists", resp.Results[2].Error)
+	}
+	if assert.NotNil(t, svc.urls["first"].OwnerID) {
+		assert.EqualValues(t, 2, *svc.urls["first"].OwnerID)
+	}
+
+	// An invalid item rejects an atomic batch before anything is created
+	w = performRequestWithKey(router, "POST", "/api/links/bulk", gin.H{"atomic": true, "items": []gin.H{
+		{"url": "https://example.com/a", "customSlug": "second"},
+		{"url": "https://example.com/b", "expirationDate": "tomorrow"},
+	}}, "alice")
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	resp = response.BulkCreateResponse{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 0, resp.Created)
+	if assert.Len(t, resp.Results, 2) {
+		assert.Equal(t, http.StatusFailedDependency, resp.Results[0].Status)
+		assert.Equal(t, http.StatusBadRequest, resp.Results[1].Status)
+	}
+	assert.NotContains(t, svc.urls, "second")
+
+	w = performRequestWithKey(router, "POST", "/api/links/bulk", gin.H{"atomic": true, "items": []gin.H{
+		{"url": "https://example.com/a", "customSlug": "third"},
+	}}, "alice")
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	items := []gin.H{{"url": "https://example.com"}, {"url": "https://example.com"}, {"url": "https://example.com"}, {"url": "https://example.com"}}
+	w = performRequestWithKey(router, "POST", "/api/links/bulk", gin.H{"items": items}, "alice")
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	w = performRequestWithKey(router, "POST", "/api/links/bulk", gin.H{"items": []gin.H{}}, "alice")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestTransferHandlers(t *testing.T) {
+	repo := repositories.NewMemoryURLRepository()
+	transfer := services.NewLinkTransferService(repo, nil, nil, services.ExpirationPolicy{})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.APIKeyAuth(testKeys, false))
+	controller := NewTransferController(transfer, 512)
+	router.GET("/api/links/export", controller.ExportLinks)
+	router.POST("/api/links/import", controller.ImportLinks)
+
+	importCSV := func(query, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/links/import"+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/csv")
+		req.Header.Set("X-API-Key", "alice")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	csvFile := "shortLink,originalUrl\nimp1,https://example.com/1\nimp2,nope\n"
+	w := importCSV("?dryRun=true", csvFile)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var report response.ImportReportResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, []response.ImportRowEntry{{Line: 3, ShortLink: "imp2", Action: "failed", Error: services.ErrInvalidURL.Error()}}, report.Rows)
+	_, err := repo.FindByShortLink("imp1")
+	assert.ErrorIs(t, err, repositories.ErrNotFound)
+
+	w = importCSV("", csvFile)
+	assert.Equal(t, http.StatusOK, w.Code)
+	_, err = repo.FindByShortLink("imp1")
+	assert.NoError(t, err)
+
+	w = importCSV("?conflict=merge", csvFile)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = importCSV("", "shortLink\nimp3\n")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = importCSV("", "shortLink,originalUrl\n"+strings.Repeat("imp4,https://example.com/4\n", 30))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	w = performRequestWithKey(router, "GET", "/api/links/export?format=csv", nil, "alice")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), ".csv")
+	assert.Contains(t, w.Body.String(), "imp1,https://example.com/1,example.com")
+
+	// Nothing has been streamed yet when the filters are rejected, so the error is JSON
+	w = performRequestWithKey(router, "GET", "/api/links/export?createdAfter=soon", nil, "alice")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+}
+
+func TestReservedSlugHandlers(t *testing.T) {
+	registry, err := services.NewSlugRegistry(repositories.NewMemoryReservedSlugRepository(), services.SlugRegistryConfig{})
+	assert.NoError(t, err)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.APIKeyAuth(testKeys, false))
+	controller := NewReservedSlugController(registry)
+	admin := router.Group("/api/admin", middleware.RequireAdmin())
+	admin.GET("/reserved-slugs", controller.ListReservedSlugs)
+	admin.POST("/reserved-slugs", controller.AddReservedSlug)
+	admin.DELETE("/reserved-slugs/:id", controller.RemoveReservedSlug)
+
+	w := performRequestWithKey(router, "POST", "/api/admin/reserved-slugs", gin.H{"value": "promo"}, "alice")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = performRequestWithKey(router, "POST", "/api/admin/reserved-slugs", gin.H{"value": "Promo", "note": "campaign"}, "admin")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created response.ReservedSlugResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "promo", created.Value)
+	assert.Equal(t, services.ReservedAdmin, created.Source)
+
+	w = performRequestWithKey(router, "POST", "/api/admin/reserved-slugs", gin.H{"value": "promo"}, "admin")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = performRequestWithKey(router, "POST", "/api/admin/reserved-slugs", gin.H{"value": "(", "pattern": true}, "admin")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = performRequestWithKey(router, "GET", "/api/admin/reserved-slugs", nil, "admin")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list response.ReservedSlugListResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Contains(t, list.ReservedSlugs, response.ReservedSlugResponse{Value: "ping", Source: services.ReservedBuiltIn})
+	assert.Equal(t, created, list.ReservedSlugs[len(list.ReservedSlugs)-1])
+
+	w = performRequestWithKey(router, "DELETE", fmt.Sprintf("/api/admin/reserved-slugs/%d", created.ID), nil, "admin")
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = performRequestWithKey(router, "DELETE", fmt.Sprintf("/api/admin/reserved-slugs/%d", created.ID), nil, "admin")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = performRequestWithKey(router, "DELETE", "/api/admin/reserved-slugs/ping", nil, "admin")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRestoreURLHandler(t *testing.T) {
+	urlRepo := repositories.NewMemoryURLRepository()
+	urls := services.NewURLService(urlRepo, nil, nil, services.ExpirationPolicy{})
+	lifecycle := services.NewLinkLifecycleService(urlRepo, repositories.NewMemoryClickRepository(), services.LinkLifecycleConfig{})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.APIKeyAuth(testKeys, false))
+	router.POST("/api/links/:shortLink/restore", NewLifecycleController(lifecycle).RestoreURL)
+
+	_, err := urls.CreateURL(services.CreateURLParams{OriginalURL: "https://example.com", CustomSlug: "back", Owner: testKeys["alice"]})
+	assert.NoError(t, err)
+	w := performRequestWithKey(router, "POST", "/api/links/back/restore", nil, "alice")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	assert.NoError(t, urls.DeleteURL("back", testKeys["alice"]))
+	w = performRequestWithKey(router, "POST", "/api/links/back/restore", nil, "bob")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = performRequestWithKey(router, "POST", "/api/links/back/restore", nil, "alice")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp response.URLResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "https://example.com", resp.OriginalURL)
+
+	w = performRequestWithKey(router, "POST", "/api/links/gone/restore", nil, "alice")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestServiceErrorMapping(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"not found", services.ErrURLNotFound, http.StatusNotFound},
+		{"expired", services.ErrURLExpired, http.StatusGone},
+		{"wrapped expired", errors.Join(errors.New("lookup"), services.ErrURLExpired), http.StatusGone},
+		{"deleted", services.ErrURLDeleted, http.StatusGone},
+		{"slug space exhausted", services.ErrSlugSpaceExhausted, http.StatusServiceUnavailable},
+		{"unavailable strategy", services.ErrUnknownSlugStrategy, http.StatusBadRequest},
+		{"restore window passed", services.ErrRestoreExpired, http.StatusGone},
+		{"beyond max lifetime", services.ErrExpirationTooLate, http.StatusBadRequest},
+		{"unknown", errors.New("connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newFakeURLService()
+			svc.err = tt.err
+			w := performRequest(newTestRouter(svc), "GET", "/anything", nil)
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
+
+func TestPreActivationResponses(t *testing.T) {
+	launch := time.Date(2030, 3, 1, 9, 0, 0, 0, time.UTC)
+	svc := newFakeURLService()
+	svc.err = &services.NotActiveError{ActivatesAt: launch}
+	newRouter := func(config RedirectConfig) *gin.Engine {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.GET("/:shortLink", NewURLController(svc, &fakeAnalyticsService{}, config).RedirectToURL)
+		return router
+	}
+
+	w := performRequest(newRouter(RedirectConfig{}), "GET", "/soon", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+
+	w = performRequest(newRouter(RedirectConfig{PreActivation: PreActivationPage}), "GET", "/soon", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), `datetime="2030-03-01T09:00:00Z"`)
+
+	w = performRequest(newRouter(RedirectConfig{PreActivation: PreActivationRedirect, PreActivationURL: "https://example.com/teaser"}), "GET", "/soon", nil)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://example.com/teaser", w.Header().Get("Location"))
+}
+
+func TestUnavailableResponses(t *testing.T) {
+	svc := newFakeURLService()
+	newRouter := func(config RedirectConfig) *gin.Engine {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.GET("/:shortLink", NewURLController(svc, &fakeAnalyticsService{}, config).RedirectToURL)
+		return router
+	}
+	browse := func(router *gin.Engine) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/ended", nil)
+		req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// The link's own fallback wins over the global one
+	svc.err = &services.UnavailableError{Reason: services.ErrURLExpired, FallbackURL: "https://example.com/own"}
+	w := performRequest(newRouter(RedirectConfig{FallbackURL: "https://example.com/global"}), "GET", "/ended", nil)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://example.com/own", w.Header().Get("Location"))
+
+	svc.err = &services.UnavailableError{Reason: services.ErrURLExpired}
+	w = performRequest(newRouter(RedirectConfig{FallbackURL: "https://example.com/global"}), "GET", "/ended", nil)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://example.com/global", w.Header().Get("Location"))
+
+	// Without fallback URLs browsers get a page and API clients JSON
+	w = browse(newRouter(RedirectConfig{FallbackPage: true}))
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), "URL has expired")
+	w = performRequest(newRouter(RedirectConfig{FallbackPage: true}), "GET", "/ended", nil)
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	w = browse(newRouter(RedirectConfig{}))
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+
+	svc.err = &services.UnavailableError{Reason: services.ErrURLDeleted}
+	w = browse(newRouter(RedirectConfig{FallbackPage: true}))
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.Contains(t, w.Body.String(), "URL has been deleted")
+}
+
+func TestRedirectAndDeleteHandlers(t *testing.T) {
+	svc := newFakeURLService()
+	svc.urls["go"] = &models.URL{OriginalURL: "https://go.dev", ShortLink: "go"}
+	analytics := &fakeAnalyticsService{}
+	router := newTestRouterWithAnalytics(svc, analytics)
+
+	req, _ := http.NewRequest("GET", "/go", nil)
+	req.Header.Set("Referer", "https://news.example.com/post")
+	req.Header.Set("CF-IPCountry", "nl")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://go.dev", w.Header().Get("Location"))
+	if assert.Len(t, analytics.visits, 1) {
+		assert.Equal(t, "https://news.example.com/post", analytics.visits[0].Referrer)
+		assert.Equal(t, "nl", analytics.visits[0].Country)
+	}
+
+	// Anonymous links can only be deleted by an admin
+	w = performRequest(router, "DELETE", "/go", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = performRequestWithKey(router, "DELETE", "/go", nil, "admin")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = performRequest(router, "GET", "/go", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestOwnershipEnforcement(t *testing.T) {
+	svc := newFakeURLService()
+	router := newTestRouter(svc)
+
+	w := performRequestWithKey(router, "POST", "/generate/shortlink", gin.H{"url": "https://example.com", "customSlug": "owned"}, "alice")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	if assert.NotNil(t, svc.urls["owned"].OwnerID) {
+		assert.EqualValues(t, 2, *svc.urls["owned"].OwnerID)
+	}
+
+	w = performRequestWithKey(router, "DELETE", "/owned", nil, "bob")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = performRequestWithKey(router, "DELETE", "/owned", nil, "not-a-key")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = performRequestWithKey(router, "DELETE", "/owned", nil, "alice")
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestUpdateURLHandler(t *testing.T) {
+	svc := newFakeURLService()
+	ownerID := uint(2)
+	svc.urls["typo"] = &models.URL{OriginalURL: "https://exmaple.com", ShortLink: "typo", OwnerID: &ownerID}
+	router := newTestRouter(svc)
+
+	w := performRequestWithKey(router, "PATCH", "/api/links/typo", gin.H{"url": "https://example.com", "expirationDate": "2030-01-02"}, "alice")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp response.URLResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "https://example.com", resp.OriginalURL)
+	assert.Equal(t, time.Date(2030, 1, 2, 0, 0, 0, 0, time.UTC), resp.ExpirationDate)
+
+	w = performRequestWithKey(router, "PATCH", "/api/links/typo", gin.H{"neverExpires": true}, "alice")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.NeverExpires, svc.urls["typo"].ExpirationDate)
+
+	w = performRequestWithKey(router, "PATCH", "/api/links/typo", gin.H{"url": "not a url"}, "alice")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = performRequestWithKey(router, "PATCH", "/api/links/typo", gin.H{"url": "https://evil.example"}, "bob")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "https://example.com", svc.urls["typo"].OriginalURL)
+}
+
+func TestListURLsHandler(t *testing.T) {
+	svc := newFakeURLService()
+	svc.listPage = services.URLPage{
+		URLs:       []models.URL{{OriginalURL: "https://go.dev", ShortLink: "go"}},
+		NextCursor: "next",
+	}
+	router := newTestRouter(svc)
+
+	w := performRequestWithKey(router, "GET", "/api/links?createdAfter=2024-01-01&status=active&domain=go.dev&slugPrefix=g&q=dev&sort=-short_link&limit=5&cursor=abc", nil, "alice")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp response.LinkListResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	if assert.Len(t, resp.Links, 1) {
+		assert.Equal(t, "go", resp.Links[0].ShortLink)
+	}
+	assert.Equal(t, "next", resp.NextCursor)
+	assert.Equal(t, services.ListURLsParams{
+		LinkFilter: services.LinkFilter{
+			CreatedAfter: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			Status:       "active",
+			Domain:       "go.dev",
+			SlugPrefix:   "g",
+			Search:       "dev",
+		},
+		Sort:   "-short_link",
+		Cursor: "abc",
+		Limit:  5,
+	}, svc.listParams)
+
+	for _, query := range []string{"status=deleted", "limit=500", "createdBefore=yesterday"} {
+		w = performRequestWithKey(router, "GET", "/api/links?"+query, nil, "alice")
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+
+	svc.err = fmt.Errorf("%w: malformed cursor", services.ErrInvalidListQuery)
+	w = performRequestWithKey(router, "GET", "/api/links?cursor=garbage", nil, "alice")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "malformed cursor")
+}
+
+func TestCheckSlugAvailabilityHandler(t *testing.T) {
+	svc := newFakeURLService()
+	svc.urls["promo"] = &models.URL{ShortLink: "promo"}
+	router := newTestRouter(svc)
+
+	w := performRequest(router, "GET", "/api/slugs/availability?customSlug=promo", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp response.SlugAvailabilityResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.False(t, resp.Available)
+	assert.Equal(t, "taken", resp.Reason)
+	assert.Equal(t, "This slug is already in use", resp.Message)
+	assert.Equal(t, []string{"promo1"}, resp.Suggestions)
+
+	w = performRequest(router, "GET", "/api/slugs/availability?customSlug=fresh", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"slug":"fresh","available":true}`, w.Body.String())
+
+	w = performRequest(router, "GET", "/api/slugs/availability", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPingHandler(t *testing.T) {
+	svc := newFakeURLService()
+	svc.pingErr = errors.New("down")
+	w := performRequest(newTestRouter(svc), "GET", "/ping", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
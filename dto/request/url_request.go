@@ -1,15 +1,85 @@
 package request
 
+// CreateURLRequest sets the expiration with at most one of ExpirationDate, TTL and
+// NeverExpires; without any the server default applies.
 type CreateURLRequest struct {
-	URL            string `json:"url" binding:"required,url"`
-	CustomSlug     string `json:"customSlug" binding:"omitempty,alphanum,min=3,max=8"`
-	ExpirationDate string `json:"expirationDate" binding:"omitempty,datetime=2006-01-02"`
+	URL        string `json:"url" binding:"required,url"`
+	CustomSlug string `json:"customSlug" binding:"omitempty,alphanum,min=3,max=8"`
+	// ExpirationDate is RFC 3339, or YYYY-MM-DD for midnight UTC
+	ExpirationDate string `json:"expirationDate"`
+	// TTL is a Go duration from now, e.g. "90m" or "72h"
+	TTL          string `json:"ttl" binding:"omitempty,excluded_with=ExpirationDate"`
+	NeverExpires bool   `json:"neverExpires" binding:"excluded_with=ExpirationDate TTL"`
+	// ActivatesAt is an RFC 3339 time before which the link doesn't resolve
+	ActivatesAt string `json:"activatesAt"`
+	// FallbackURL receives visitors once the link expired or was deleted
+	FallbackURL string `json:"fallbackUrl" binding:"omitempty,url"`
+	// SlugStrategy overrides the server's slug generator for this link
+	SlugStrategy string `json:"slugStrategy" binding:"omitempty,oneof=random sequential words,excluded_with=CustomSlug"`
 }
 
+// ValidateSlugRequest only requires the slug to be present: format problems are reported
+// as reasons in the availability response rather than as binding errors.
 type ValidateSlugRequest struct {
-	CustomSlug string `json:"customSlug" binding:"required,alphanum,min=3,max=8"`
+	CustomSlug string `json:"customSlug" form:"customSlug" binding:"required"`
 }
 
-type UpdateExpirationRequest struct {
-	ExpirationDate string `json:"expirationDate" binding:"required,datetime=2006-01-02"`
+// UpdateURLRequest is a partial update: only fields present in the body are changed.
+// The expiration and activation are set like in CreateURLRequest.
+type UpdateURLRequest struct {
+	URL            *string `json:"url" binding:"omitempty,url"`
+	ExpirationDate *string `json:"expirationDate"`
+	TTL            string  `json:"ttl" binding:"omitempty,excluded_with=ExpirationDate"`
+	NeverExpires   bool    `json:"neverExpires" binding:"excluded_with=ExpirationDate TTL"`
+	// ActivatesAt reschedules the link's activation; "" activates it right away
+	ActivatesAt *string `json:"activatesAt"`
+	// FallbackURL replaces the link's fallback; "" removes it
+	FallbackURL *string `json:"fallbackUrl" binding:"omitempty,url|len=0"`
+}
+
+// LinkFilterRequest holds the link filters shared by listing and export. Dates accept
+// RFC 3339 or YYYY-MM-DD.
+type LinkFilterRequest struct {
+	CreatedAfter  string `form:"createdAfter"`
+	CreatedBefore string `form:"createdBefore"`
+	Status        string `form:"status" binding:"omitempty,oneof=active expired all"`
+	Domain        string `form:"domain"`
+	SlugPrefix    string `form:"slugPrefix"`
+	Search        string `form:"q"`
+}
+
+// ListURLsRequest holds the query parameters of GET /api/links.
+type ListURLsRequest struct {
+	LinkFilterRequest
+	Sort   string `form:"sort"`
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// ExportLinksRequest holds the query parameters of GET /api/links/export.
+type ExportLinksRequest struct {
+	LinkFilterRequest
+	Format string `form:"format" binding:"omitempty,oneof=csv jsonl"`
+}
+
+// ImportLinksRequest holds the query parameters of POST /api/links/import; the file is the body.
+type ImportLinksRequest struct {
+	Format   string `form:"format" binding:"omitempty,oneof=csv jsonl"`
+	Conflict string `form:"conflict" binding:"omitempty,oneof=skip overwrite rename"`
+	DryRun   bool   `form:"dryRun"`
+}
+
+// BulkCreateURLRequest shortens many URLs at once. Items are validated one by one so a bad
+// item doesn't fail the whole request unless Atomic is set.
+type BulkCreateURLRequest struct {
+	Items  []CreateURLRequest `json:"items" binding:"required,min=1"`
+	Atomic bool               `json:"atomic"`
+}
+
+// AddReservedSlugRequest reserves a word, matched against whole slugs, or with Pattern set a
+// regular expression matched against any part of a slug. Both ignore case.
+type AddReservedSlugRequest struct {
+	Value   string `json:"value" binding:"required,max=255"`
+	Pattern bool   `json:"pattern"`
+	Note    string `json:"note" binding:"max=255"`
 }
@@ -3,9 +3,27 @@ package response
 import "time"
 
 type URLResponse struct {
-	OriginalURL    string    `json:"originalUrl"`
-	ShortLink      string    `json:"shortLink"`
-	ExpirationDate time.Time `json:"expirationDate"`
+	OriginalURL    string     `json:"originalUrl"`
+	ShortLink      string     `json:"shortLink"`
+	ExpirationDate time.Time  `json:"expirationDate"` // 9999-12-31 for permanent links
+	NeverExpires   bool       `json:"neverExpires,omitempty"`
+	ActivatesAt    *time.Time `json:"activatesAt,omitempty"`
+	FallbackURL    string     `json:"fallbackUrl,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// LinkListResponse is one page of GET /api/links; pass NextCursor back as ?cursor= for the next page.
+type LinkListResponse struct {
+	Links      []URLResponse `json:"links"`
+	NextCursor string        `json:"nextCursor,omitempty"`
+}
+
+type SlugAvailabilityResponse struct {
+	Slug        string   `json:"slug"`
+	Available   bool     `json:"available"`
+	Reason      string   `json:"reason,omitempty"`
+	Message     string   `json:"message,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
 }
 
 type ErrorResponse struct {
@@ -16,3 +34,75 @@ type ErrorResponse struct {
 type MessageResponse struct {
 	Message string `json:"message"`
 }
+
+type LinkStatsResponse struct {
+	ShortLink      string            `json:"shortLink"`
+	TotalClicks    int64             `json:"totalClicks"`
+	From           time.Time         `json:"from"`
+	To             time.Time         `json:"to"`
+	Clicks         int               `json:"clicks"`
+	UniqueVisitors int               `json:"uniqueVisitors"`
+	Daily          []TimeSeriesPoint `json:"daily"`
+	Hourly         []TimeSeriesPoint `json:"hourly"`
+	TopReferrers   []CountEntry      `json:"topReferrers"`
+	TopUserAgents  []CountEntry      `json:"topUserAgents"`
+	TopCountries   []CountEntry      `json:"topCountries"`
+}
+
+type TimeSeriesPoint struct {
+	Start  time.Time `json:"start"`
+	Clicks int       `json:"clicks"`
+}
+
+type CountEntry struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// BulkCreateResponse reports the outcome of every item of a bulk create, in request order.
+type BulkCreateResponse struct {
+	Created int               `json:"created"`
+	Failed  int               `json:"failed"`
+	Results []BulkItemOutcome `json:"results"`
+}
+
+type BulkItemOutcome struct {
+	Index  int          `json:"index"`
+	Status int          `json:"status"`
+	Link   *URLResponse `json:"link,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// ImportReportResponse summarises an import. Rows lists every row that wasn't simply created.
+type ImportReportResponse struct {
+	DryRun      bool             `json:"dryRun"`
+	Created     int              `json:"created"`
+	Overwritten int              `json:"overwritten"`
+	Renamed     int              `json:"renamed"`
+	Skipped     int              `json:"skipped"`
+	Failed      int              `json:"failed"`
+	Rows        []ImportRowEntry `json:"rows"`
+}
+
+type ImportRowEntry struct {
+	Line        int    `json:"line"`
+	ShortLink   string `json:"shortLink,omitempty"`
+	Action      string `json:"action"`
+	RenamedFrom string `json:"renamedFrom,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ReservedSlugResponse is one reserved word or pattern. Source is builtin, file or admin;
+// only admin entries have an ID and can be removed.
+type ReservedSlugResponse struct {
+	ID        uint       `json:"id,omitempty"`
+	Value     string     `json:"value"`
+	Pattern   bool       `json:"pattern"`
+	Note      string     `json:"note,omitempty"`
+	Source    string     `json:"source"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
+
+type ReservedSlugListResponse struct {
+	ReservedSlugs []ReservedSlugResponse `json:"reservedSlugs"`
+}
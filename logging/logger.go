@@ -12,6 +12,10 @@ var Log = logrus.New()
 func init() {
 	Log.SetFormatter(&logrus.JSONFormatter{})
 	Log.SetOutput(os.Stdout)
+	if len(os.Args) > 1 {
+		// Subcommands such as "links export" write their results to stdout, so keep logs off it
+		Log.SetOutput(os.Stderr)
+	}
 	logLevel := os.Getenv("LOG_LEVEL")
 	switch strings.ToLower(logLevel) {
 	case "debug":
@@ -1,47 +1,215 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"expvar"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
+	"url-shortener/cli"
 	"url-shortener/config" // Added for SetupDatabase
 	"url-shortener/controllers"
 	"url-shortener/logging" // Added for logrus
 	"url-shortener/middleware"
-	"url-shortener/models" // models is used by config.SetupDatabase if it still does AutoMigrate
+	"url-shortener/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
-	// "gorm.io/driver/mysql" // No longer needed directly in main.go
-	"gorm.io/gorm"
 )
 
+// shutdownTimeout bounds how long in-flight requests may take to finish on shutdown
+const shutdownTimeout = 15 * time.Second
+
 // Struct definitions (URL, CreateURLRequest, URLResponse) are removed.
 // generateRandomSlug function is removed (assuming it's in utils/random.go).
 // setupDatabase function is removed (moved to config/database.go).
 
-func setupRouter(db *gorm.DB) *gin.Engine {
+// appServices holds the services the router is built from
+type appServices struct {
+	urls          services.URLService
+	analytics     services.AnalyticsService
+	apiKeys       services.APIKeyService
+	transfer      services.LinkTransferService
+	lifecycle     services.LinkLifecycleService
+	clickRecorder *services.ClickRecorder // nil when clicks are written synchronously
+	expirySweeper *services.ExpirySweeper // nil when disabled
+	slugs         *services.SlugStrategies
+	reservedSlugs *services.SlugRegistry
+	rateLimits    middleware.RateLimitStore
+	rateLimit     config.RateLimitConfig
+	clientIPs     *middleware.ClientIPResolver
+}
+
+// newServices wires repositories -> services for the configured storage
+func newServices(storage *config.Storage) (*appServices, error) {
+	analyticsConfig := config.LoadAnalyticsConfig()
+	slugs, err := services.NewSlugGenerator(services.SlugGeneratorConfig(config.LoadSlugConfig()), storage.Sequences)
+	if err != nil {
+		return nil, fmt.Errorf("invalid slug settings: %w", err)
+	}
+
+	reservedSlugs, err := services.NewSlugRegistry(storage.Reserved, services.SlugRegistryConfig(config.LoadSlugRegistryConfig()))
+	if err != nil {
+		return nil, fmt.Errorf("invalid reserved slug settings: %w", err)
+	}
+
+	var clickRecorder *services.ClickRecorder
+	if analyticsConfig.AsyncClicks {
+		clickRecorder = services.NewClickRecorder(storage.Clicks, services.ClickRecorderConfig{
+			BufferSize:    analyticsConfig.BufferSize,
+			BatchSize:     analyticsConfig.BatchSize,
+			FlushInterval: analyticsConfig.FlushInterval,
+			Workers:       analyticsConfig.Workers,
+			Policy:        services.BackpressurePolicy(analyticsConfig.Backpressure),
+		})
+	}
+
+	expiry := services.ExpirationPolicy(config.LoadExpirationPolicy())
+	lifecycle := services.NewLinkLifecycleService(storage.URLs, storage.Clicks, services.LinkLifecycleConfig(config.LoadLinkLifecycleConfig()))
+	var expirySweeper *services.ExpirySweeper
+	if config.ExpirySweeperEnabled() {
+		expirySweeper = services.NewExpirySweeper(storage.URLs, lifecycle, storage.Locks, services.ExpirySweeperConfig(config.LoadExpirySweeperConfig()))
+	}
+
+	clientIPs, err := middleware.NewClientIPResolver(middleware.ClientIPConfig(config.LoadClientIPConfig()))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+
+	rateLimitConfig := config.LoadRateLimitConfig()
+	rateLimits, err := newRateLimitStore(rateLimitConfig, storage)
+	if err != nil {
+		return nil, err
+	}
+	logging.Log.WithField("store", rateLimitConfig.Store).Info("Loaded rate limiter configuration")
+
+	return &appServices{
+		urls:          services.NewURLService(storage.URLs, slugs, reservedSlugs, expiry),
+		analytics:     services.NewAnalyticsService(storage.URLs, storage.Clicks, clickRecorder, analyticsConfig.IPSalt),
+		apiKeys:       services.NewAPIKeyService(storage.APIKeys),
+		transfer:      services.NewLinkTransferService(storage.URLs, slugs, reservedSlugs, expiry),
+		lifecycle:     lifecycle,
+		clickRecorder: clickRecorder,
+		expirySweeper: expirySweeper,
+		slugs:         slugs,
+		reservedSlugs: reservedSlugs,
+		rateLimits:    rateLimits,
+		rateLimit:     rateLimitConfig,
+		clientIPs:     clientIPs,
+	}, nil
+}
+
+// rateLimiter limits a route group to policy
+func (app *appServices) rateLimiter(group string, policy config.RateLimitPolicy) gin.HandlerFunc {
+	return middleware.RateLimiter(app.rateLimits, middleware.RateLimitPolicy{
+		Group:  group,
+		PerIP:  middleware.RateLimit(policy.PerIP),
+		PerKey: middleware.RateLimit(policy.PerKey),
+	})
+}
+
+// newRateLimitStore returns the RATE_LIMIT_STORE the rate limiter counts requests in
+func newRateLimitStore(rateLimit config.RateLimitConfig, storage *config.Storage) (middleware.RateLimitStore, error) {
+	switch store := rateLimit.Store; store {
+	case config.RateLimitStoreMemory:
+		return middleware.NewMemoryRateLimitStore(middleware.MemoryRateLimitConfig(rateLimit.Memory)), nil
+	case config.RateLimitStoreRedis:
+		if storage.Redis == nil {
+			return nil, errors.New("RATE_LIMIT_STORE=redis needs REDIS_HOST")
+		}
+		return middleware.NewRedisRateLimitStore(storage.Redis), nil
+	default:
+		return nil, fmt.Errorf("unsupported RATE_LIMIT_STORE %q (expected %s or %s)", store, config.RateLimitStoreMemory, config.RateLimitStoreRedis)
+	}
+}
+
+// close stops background workers, flushing anything they still buffer
+func (app *appServices) close() {
+	if app.expirySweeper != nil {
+		app.expirySweeper.Close()
+	}
+	if app.clickRecorder != nil {
+		app.clickRecorder.Close()
+	}
+	if store, ok := app.rateLimits.(*middleware.MemoryRateLimitStore); ok {
+		store.Close()
+	}
+}
+
+func setupRouter(app *appServices) *gin.Engine {
 	router := gin.Default()
+	// Client addresses come from ResolveClientIP, so gin's own lookup shouldn't trust anyone
+	if err := router.SetTrustedProxies(nil); err != nil {
+		panic(err) // nil is always valid
+	}
 
+	// Resolve the client address first, so every later middleware and handler sees the same one
+	router.Use(middleware.ResolveClientIP(app.clientIPs))
 	// Apply RequestLogger middleware globally - should be one of the first
 	router.Use(middleware.RequestLogger())
-	// Apply RateLimiter middleware globally
-	router.Use(middleware.RateLimiter())
 	// Apply SecurityHeaders middleware globally
 	router.Use(middleware.SecurityHeaders())
 
+	// API key authentication: optional where anonymous use is allowed, required to modify links.
+	// limitAuth goes first, so that wrong keys can't be tried at any rate.
+	limitAuth := middleware.AuthRateLimiter(app.rateLimits, middleware.RateLimit(app.rateLimit.Auth))
+	authenticate := middleware.APIKeyAuth(app.apiKeys, false)
+	requireAPIKey := middleware.APIKeyAuth(app.apiKeys, true)
+
+	// Rate limits per route group. They come after authentication, which identifies clients
+	// with an API key.
+	limitRedirects := app.rateLimiter("redirect", app.rateLimit.Redirect)
+	limitCreates := app.rateLimiter("create", app.rateLimit.Create)
+	limitAdmin := app.rateLimiter("admin", app.rateLimit.Admin)
+	limit := app.rateLimiter("default", app.rateLimit.Default)
+
 	// Initialize controller
-	urlController := controllers.NewURLController(db)
+	urlController := controllers.NewURLController(app.urls, app.analytics, controllers.RedirectConfig(config.LoadRedirectConfig()))
+	analyticsController := controllers.NewAnalyticsController(app.analytics)
+	urlConfig := config.LoadURLConfig()
+	bulkController := controllers.NewBulkController(app.urls, urlConfig.BulkMaxItems)
+	transferController := controllers.NewTransferController(app.transfer, urlConfig.ImportMaxBytes)
+	reservedSlugController := controllers.NewReservedSlugController(app.reservedSlugs)
+	lifecycleController := controllers.NewLifecycleController(app.lifecycle)
 
 	// Add ping endpoint for health check
-	router.GET("/ping", urlController.Ping) // Use the Ping method from URLController
+	router.GET("/ping", limit, urlController.Ping) // Use the Ping method from URLController
 
 	// Setup routes
-	router.POST("/generate/shortlink", urlController.CreateShortURL)
-	router.GET("/:shortLink", urlController.RedirectToURL)
-	router.DELETE("/:shortLink", urlController.DeleteShortURL)
+	router.POST("/generate/shortlink", limitAuth, authenticate, limitCreates, urlController.CreateShortURL)
+	router.GET("/:shortLink", limitRedirects, urlController.RedirectToURL)
+	router.DELETE("/:shortLink", limitAuth, requireAPIKey, limit, urlController.DeleteShortURL)
 	// Removed direct handler implementations
 
+	api := router.Group("/api")
+	api.GET("/links", limitAuth, requireAPIKey, limit, urlController.ListURLs)
+	api.POST("/links/bulk", limitAuth, requireAPIKey, limitCreates, bulkController.CreateShortURLs)
+	api.GET("/links/export", limitAuth, requireAPIKey, limit, transferController.ExportLinks)
+	api.POST("/links/import", limitAuth, requireAPIKey, limitCreates, transferController.ImportLinks)
+	api.PATCH("/links/:shortLink", limitAuth, requireAPIKey, limit, urlController.UpdateURL)
+	api.POST("/links/:shortLink/restore", limitAuth, requireAPIKey, limit, lifecycleController.RestoreURL)
+	api.GET("/links/:shortLink/stats", limitAuth, requireAPIKey, limit, analyticsController.GetLinkStats)
+	api.GET("/slugs/availability", limit, urlController.CheckSlugAvailability)
+
+	admin := api.Group("/admin", limitAuth, requireAPIKey, middleware.RequireAdmin(), limitAdmin)
+	admin.GET("/reserved-slugs", reservedSlugController.ListReservedSlugs)
+	admin.POST("/reserved-slugs", reservedSlugController.AddReservedSlug)
+	admin.DELETE("/reserved-slugs/:id", reservedSlugController.RemoveReservedSlug)
+
+	// Runtime counters (click pipeline, ...) published through expvar
+	router.GET("/debug/vars", limitAuth, requireAPIKey, middleware.RequireAdmin(), limitAdmin, gin.WrapH(expvar.Handler()))
+
+	// Links may not shadow any route registered above, present or future
+	var paths []string
+	for _, route := range router.Routes() {
+		paths = append(paths, route.Path)
+	}
+	app.reservedSlugs.ReserveRoutes(paths)
+
 	return router
 }
 
@@ -54,14 +222,50 @@ func main() {
 		logging.Log.WithError(err).Fatal("Error loading .env file")
 	}
 
-	// Setup database
-	db, err := config.SetupDatabase() // Use config.SetupDatabase
+	// Administrative subcommands (e.g. "migrate up") run instead of the server
+	if len(os.Args) > 1 {
+		if err := cli.Run(os.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Setup storage (driver selected by STORAGE_DRIVER)
+	storage, err := config.SetupStorage()
 	if err != nil {
-		logging.Log.WithError(err).Fatal("Database setup failed")
+		logging.Log.WithError(err).Fatal("Storage setup failed")
+	}
+
+	app, err := newServices(storage)
+	if err != nil {
+		logging.Log.WithError(err).Fatal("Service setup failed")
+	}
+
+	// ADMIN_API_KEY bootstraps an admin key, e.g. for the memory driver where the
+	// apikey command can't reach the server's store
+	if adminKey := os.Getenv("ADMIN_API_KEY"); adminKey != "" {
+		if _, err := app.apiKeys.EnsureKey(adminKey, "bootstrap-admin", true); err != nil {
+			logging.Log.WithError(err).Fatal("Failed to register ADMIN_API_KEY")
+		}
+	}
+	expvar.Publish("slug_generator", expvar.Func(func() any { return app.slugs.Stats() }))
+	if storage.URLCache != nil {
+		expvar.Publish("url_cache", expvar.Func(func() any { return storage.URLCache.Stats() }))
+	}
+	if app.clickRecorder != nil {
+		expvar.Publish("click_recorder", expvar.Func(func() any { return app.clickRecorder.Stats() }))
+	}
+	if store, ok := app.rateLimits.(*middleware.MemoryRateLimitStore); ok {
+		expvar.Publish("rate_limiter", expvar.Func(func() any { return store.Stats() }))
+	}
+	if app.expirySweeper != nil {
+		expvar.Publish("expiry_sweeper", expvar.Func(func() any { return app.expirySweeper.Stats() }))
+		app.expirySweeper.Start()
 	}
 
 	// Setup router
-	router := setupRouter(db)
+	router := setupRouter(app)
 
 	// Get port from environment variable or use default
 	port := os.Getenv("PORT")
@@ -69,9 +273,25 @@ func main() {
 		port = "8080" // Default port
 	}
 
-	logging.Log.Infof("Starting server on port %s", port)
-	// Start server
-	if err := router.Run(":" + port); err != nil {
-		logging.Log.WithError(err).Fatal("Failed to start server")
+	server := &http.Server{Addr: ":" + port, Handler: router}
+	go func() {
+		logging.Log.Infof("Starting server on port %s", port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Log.WithError(err).Fatal("Failed to start server")
+		}
+	}()
+
+	// Wait for SIGINT/SIGTERM, then drain in-flight requests before flushing background work
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	logging.Log.Info("Shutting down server")
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		logging.Log.WithError(err).Error("Server forced to shut down")
 	}
+	app.close()
+	logging.Log.Info("Server stopped")
 }
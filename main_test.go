@@ -10,20 +10,32 @@ import (
 	"os"
 	"testing"
 	"time"
+	"url-shortener/config"
+	"url-shortener/dto/request"
+	"url-shortener/dto/response"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/mysql"
-	"gorm.io/gorm"
 )
 
-var testDB *gorm.DB
+var testStorage *config.Storage
+var testApp *appServices
 var testRouter *gin.Engine
 
 func TestMain(m *testing.M) {
 	// Set test environment
 	gin.SetMode(gin.TestMode)
 
+	// Default to in-memory storage so tests run without a database server.
+	// Set STORAGE_DRIVER=mysql or sqlite to run against a real database.
+	if os.Getenv("STORAGE_DRIVER") == "" {
+		os.Setenv("STORAGE_DRIVER", config.DriverMemory)
+	}
+	// Flush asynchronously recorded clicks quickly so stats tests don't wait long
+	os.Setenv("CLICK_FLUSH_INTERVAL", "10ms")
+	// cleanupTestDB deletes rows behind the link cache's back
+	os.Setenv("URL_CACHE_ENABLED", "false")
+
 	// Setup test database
 	setupTestDB()
 
@@ -32,42 +44,44 @@ func TestMain(m *testing.M) {
 
 	// Cleanup
 	cleanupTestDB()
+	testApp.close()
 
 	os.Exit(code)
 }
 
 func setupTestDB() {
 	var err error
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_NAME"))
-
-	testDB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	testStorage, err = config.SetupStorage()
 	if err != nil {
-		panic("failed to connect database")
+		panic(fmt.Sprintf("failed to set up %s storage: %v", config.StorageDriver(), err))
 	}
-
-	testDB.AutoMigrate(&URL{})
-	testRouter = setupRouter(testDB) // Pass testDB to setupRouter
+	if testApp != nil {
+		testApp.close()
+	}
+	testApp, err = newServices(testStorage)
+	if err != nil {
+		panic(err)
+	}
+	testRouter = setupRouter(testApp)
 }
 
 func cleanupTestDB() {
 	// Clean up test data
-	sqlDB, err := testDB.DB()
-	if err != nil {
-		panic("failed to get underlying sql.DB")
+	if testStorage.DB == nil {
+		// Nothing to truncate for the memory driver; start from a fresh store instead
+		setupTestDB()
+		return
 	}
-	sqlDB.Exec("DELETE FROM urls")
+	testStorage.DB.Exec("DELETE FROM clicks")
+	testStorage.DB.Exec("DELETE FROM api_keys")
+	testStorage.DB.Exec("DELETE FROM urls")
 }
 
 func TestCreateShortURL(t *testing.T) {
 	cleanupTestDB() // Clean before each test
 
 	t.Run("Valid URL", func(t *testing.T) {
-		payload := CreateURLRequest{
+		payload := request.CreateURLRequest{
 			URL:            "https://www.google.com",
 			ExpirationDate: time.Now().AddDate(0, 0, 1).Format("2006-01-02"),
 		}
@@ -83,14 +97,35 @@ func TestCreateShortURL(t *testing.T) {
 
 		assert.Equal(t, 201, w.Code)
 
-		var response URLResponse
-		err = json.Unmarshal(w.Body.Bytes(), &response)
+		var resp response.URLResponse
+		err = json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, resp.ShortLink)
+	})
+
+	t.Run("Word slug", func(t *testing.T) {
+		jsonData, err := json.Marshal(request.CreateURLRequest{URL: "https://www.google.com", SlugStrategy: "words"})
 		assert.NoError(t, err)
-		assert.NotEmpty(t, response.ShortLink)
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/generate/shortlink", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		testRouter.ServeHTTP(w, req)
+		assert.Equal(t, 201, w.Code)
+
+		var resp response.URLResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Regexp(t, `^[a-z]+-[a-z]+-[0-9]+$`, resp.ShortLink)
+
+		// The link resolves like any other
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/"+resp.ShortLink, nil)
+		testRouter.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusFound, w.Code)
 	})
 
 	t.Run("Invalid URL", func(t *testing.T) {
-		payload := CreateURLRequest{
+		payload := request.CreateURLRequest{
 			URL: "invalid-url",
 		}
 
@@ -111,9 +146,9 @@ func TestRedirectURL(t *testing.T) {
 	cleanupTestDB() // Clean before test
 
 	// First create a URL
-	payload := CreateURLRequest{
+	payload := request.CreateURLRequest{
 		URL:        "https://www.google.com",
-		CustomSlug: "testredirect",
+		CustomSlug: "tredir",
 	}
 
 	jsonData, _ := json.Marshal(payload)
@@ -124,7 +159,7 @@ func TestRedirectURL(t *testing.T) {
 
 	// Test redirection
 	w = httptest.NewRecorder()
-	req, _ = http.NewRequest("GET", "/testredirect", nil)
+	req, _ = http.NewRequest("GET", "/tredir", nil)
 	testRouter.ServeHTTP(w, req)
 
 	assert.Equal(t, 302, w.Code)
@@ -135,32 +170,155 @@ func TestDeleteURL(t *testing.T) {
 	cleanupTestDB() // Clean before test
 
 	// First create a URL
-	payload := CreateURLRequest{
+	payload := request.CreateURLRequest{
 		URL:        "https://www.google.com",
-		CustomSlug: "testdelete",
+		CustomSlug: "tdelete",
 	}
 
+	ownerKey, _, err := testApp.apiKeys.CreateKey("owner", false)
+	assert.NoError(t, err)
+	otherKey, _, err := testApp.apiKeys.CreateKey("other", false)
+	assert.NoError(t, err)
+
 	jsonData, _ := json.Marshal(payload)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/generate/shortlink", bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+ownerKey)
+	testRouter.ServeHTTP(w, req)
+
+	// Deleting requires an API key...
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/tdelete", nil)
+	testRouter.ServeHTTP(w, req)
+	assert.Equal(t, 401, w.Code)
+
+	// ...belonging to the link's owner
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("DELETE", "/tdelete", nil)
+	req.Header.Set("Authorization", "Bearer "+otherKey)
 	testRouter.ServeHTTP(w, req)
+	assert.Equal(t, 403, w.Code)
 
 	// Delete the URL
 	w = httptest.NewRecorder()
-	req, _ = http.NewRequest("DELETE", "/testdelete", nil)
+	req, _ = http.NewRequest("DELETE", "/tdelete", nil)
+	req.Header.Set("Authorization", "Bearer "+ownerKey)
 	testRouter.ServeHTTP(w, req)
 
 	assert.Equal(t, 200, w.Code)
 
-	// Verify deletion
+	// Verify deletion: the tombstone answers until it is purged
 	w = httptest.NewRecorder()
-	req, _ = http.NewRequest("GET", "/testdelete", nil)
+	req, _ = http.NewRequest("GET", "/tdelete", nil)
+	req.Header.Set("Accept", "application/json")
 	testRouter.ServeHTTP(w, req)
 
+	assert.Equal(t, 410, w.Code)
+}
+
+func TestLinkStats(t *testing.T) {
+	cleanupTestDB() // Clean before test
+
+	payload := request.CreateURLRequest{
+		URL:        "https://www.google.com",
+		CustomSlug: "tstats",
+	}
+
+	ownerKey, _, err := testApp.apiKeys.CreateKey("owner", false)
+	assert.NoError(t, err)
+	otherKey, _, err := testApp.apiKeys.CreateKey("other", false)
+	assert.NoError(t, err)
+
+	jsonData, _ := json.Marshal(payload)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/generate/shortlink", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+ownerKey)
+	testRouter.ServeHTTP(w, req)
+
+	// Two redirects from the same client
+	for i := 0; i < 2; i++ {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/tstats", nil)
+		req.Header.Set("User-Agent", "Mozilla/5.0 Firefox/125.0")
+		testRouter.ServeHTTP(w, req)
+		assert.Equal(t, 302, w.Code)
+	}
+
+	// Clicks are written in the background, so wait for the recorder to flush them
+	var stats response.LinkStatsResponse
+	assert.Eventually(t, func() bool {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/api/links/tstats/stats", nil)
+		req.Header.Set("Authorization", "Bearer "+ownerKey)
+		testRouter.ServeHTTP(w, req)
+		return w.Code == 200 && json.Unmarshal(w.Body.Bytes(), &stats) == nil && stats.TotalClicks == 2
+	}, time.Second, 20*time.Millisecond)
+	assert.Equal(t, 1, stats.UniqueVisitors)
+	assert.Equal(t, []response.CountEntry{{Value: "Firefox", Count: 2}}, stats.TopUserAgents)
+
+	// Stats are for the link's owner only
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/links/tstats/stats", nil)
+	testRouter.ServeHTTP(w, req)
+	assert.Equal(t, 401, w.Code)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/links/tstats/stats", nil)
+	req.Header.Set("Authorization", "Bearer "+otherKey)
+	testRouter.ServeHTTP(w, req)
+	assert.Equal(t, 403, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/api/links/missing/stats", nil)
+	req.Header.Set("Authorization", "Bearer "+ownerKey)
+	testRouter.ServeHTTP(w, req)
 	assert.Equal(t, 404, w.Code)
 }
 
+func TestListLinks(t *testing.T) {
+	cleanupTestDB() // Clean before test
+
+	ownerKey, _, err := testApp.apiKeys.CreateKey("owner", false)
+	assert.NoError(t, err)
+
+	for _, slug := range []string{"tlist1", "tlist2", "tlist3"} {
+		jsonData, _ := json.Marshal(request.CreateURLRequest{URL: "https://example.com/" + slug, CustomSlug: slug})
+		req, _ := http.NewRequest("POST", "/generate/shortlink", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+ownerKey)
+		testRouter.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Listing requires an API key
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/links", nil)
+	testRouter.ServeHTTP(w, req)
+	assert.Equal(t, 401, w.Code)
+
+	// Newest first, two per page
+	var slugs []string
+	path := "/api/links?limit=2"
+	for path != "" {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+ownerKey)
+		testRouter.ServeHTTP(w, req)
+		assert.Equal(t, 200, w.Code)
+
+		var page response.LinkListResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		for _, link := range page.Links {
+			slugs = append(slugs, link.ShortLink)
+		}
+		path = ""
+		if page.NextCursor != "" {
+			path = "/api/links?limit=2&cursor=" + page.NextCursor
+		}
+	}
+	assert.Equal(t, []string{"tlist3", "tlist2", "tlist1"}, slugs)
+}
+
 func TestPingEndpoint(t *testing.T) {
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/ping", nil)
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"url-shortener/logging"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyContextKey is where APIKeyAuth stores the authenticated key in the gin context.
+const apiKeyContextKey = "apiKey"
+
+// APIKeyAuthenticator resolves a plaintext API key; services.APIKeyService satisfies it.
+type APIKeyAuthenticator interface {
+	Authenticate(plaintext string) (*models.APIKey, error)
+}
+
+// APIKeyAuth authenticates the key sent as "Authorization: Bearer <key>" or "X-API-Key: <key>".
+// When required is false, requests without a key continue anonymously; a key that is sent
+// but invalid is always rejected.
+func APIKeyAuth(authenticator APIKeyAuthenticator, required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plaintext := apiKeyFromRequest(c)
+		if plaintext == "" {
+			if required {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key required"})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		key, err := authenticator.Authenticate(plaintext)
+		if err != nil {
+			logging.Log.WithError(err).WithField("path", c.Request.URL.Path).Warn("API key rejected")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+			return
+		}
+
+		c.Set(apiKeyContextKey, key)
+		c.Next()
+	}
+}
+
+// RequireAdmin rejects requests whose API key is not an admin key. It must run after APIKeyAuth.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := CurrentAPIKey(c)
+		if key == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API key required"})
+			return
+		}
+		if !key.IsAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin API key required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// CurrentAPIKey returns the key authenticated by APIKeyAuth, or nil for anonymous requests.
+func CurrentAPIKey(c *gin.Context) *models.APIKey {
+	if value, exists := c.Get(apiKeyContextKey); exists {
+		if key, ok := value.(*models.APIKey); ok {
+			return key
+		}
+	}
+	return nil
+}
+
+func apiKeyFromRequest(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); header != "" {
+		if token, found := strings.CutPrefix(header, "Bearer "); found {
+			return strings.TrimSpace(token)
+		}
+	}
+	return strings.TrimSpace(c.GetHeader("X-API-Key"))
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clientIPContextKey is where ResolveClientIP stores the client address in the gin context.
+const clientIPContextKey = "clientIP"
+
+// ClientIPConfig configures NewClientIPResolver.
+type ClientIPConfig struct {
+	// TrustedProxies are the CIDRs or addresses of the proxies in front of the server. Only
+	// requests from them may say who the client is; by default nobody may.
+	TrustedProxies []string
+	// RealIPHeader names a header a trusted proxy sets to the client address, such as
+	// X-Real-IP. It takes precedence over Forwarded and X-Forwarded-For. Optional.
+	RealIPHeader string
+}
+
+// ClientIPResolver finds the address of the client behind any trusted proxies. Without
+// trusted proxies it is the peer's address, whatever headers claim.
+type ClientIPResolver struct {
+	trusted      []netip.Prefix
+	realIPHeader string
+}
+
+func NewClientIPResolver(config ClientIPConfig) (*ClientIPResolver, error) {
+	resolver := &ClientIPResolver{realIPHeader: http.CanonicalHeaderKey(strings.TrimSpace(config.RealIPHeader))}
+	for _, value := range config.TrustedProxies {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(value)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: expected a CIDR or an IP address", value)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		resolver.trusted = append(resolver.trusted, prefix.Masked())
+	}
+	return resolver, nil
+}
+
+// ClientIP returns the client address of r. From a trusted proxy it believes, in order, the
+// real IP header, the Forwarded header and X-Forwarded-For. The forwarding chains are read
+// from the right, skipping trusted proxies, since everything left of the first untrusted hop
+// could have been written by the client. That hop may be an identifier rather than an address.
+func (r *ClientIPResolver) ClientIP(req *http.Request) string {
+	peer, ok := parseAddr(req.RemoteAddr)
+	if !ok {
+		return req.RemoteAddr
+	}
+	if !r.isTrusted(peer) {
+		return peer.String()
+	}
+
+	if r.realIPHeader != "" {
+		if addr, ok := parseAddr(req.Header.Get(r.realIPHeader)); ok {
+			return addr.String()
+		}
+	}
+	if chain := forwardedFor(req.Header.Values("Forwarded")); len(chain) > 0 {
+		return r.walk(peer, chain)
+	}
+	if chain := splitList(req.Header.Values("X-Forwarded-For")); len(chain) > 0 {
+		return r.walk(peer, chain)
+	}
+	return peer.String()
+}
+
+// walk returns the first hop of chain, right to left, that isn't a trusted proxy. A hop that
+// isn't an address, such as Forwarded's "unknown" or an obfuscated "_hidden", isn't a proxy
+// either, so it is returned as given; obfuscated identifiers are stable per client, which
+// keeps their rate limits and analytics apart.
+func (r *ClientIPResolver) walk(peer netip.Addr, chain []string) string {
+	client := peer.String()
+	for i := len(chain) - 1; i >= 0; i-- {
+		addr, ok := parseAddr(chain[i])
+		if !ok {
+			return chain[i]
+		}
+		client = addr.String()
+		if !r.isTrusted(addr) {
+			break
+		}
+	}
+	return client
+}
+
+func (r *ClientIPResolver) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedFor extracts the for= parameters of RFC 7239 Forwarded headers, in hop order.
+func forwardedFor(values []string) []string {
+	var chain []string
+	for _, element := range splitList(values) {
+		for _, pair := range strings.Split(element, ";") {
+			name, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+			if found && strings.EqualFold(name, "for") {
+				chain = append(chain, strings.Trim(value, `"`))
+			}
+		}
+	}
+	return chain
+}
+
+// splitList splits comma-separated header values into trimmed elements.
+func splitList(values []string) []string {
+	var elements []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			if element = strings.TrimSpace(element); element != "" {
+				elements = append(elements, element)
+			}
+		}
+	}
+	return elements
+}
+
+// parseAddr parses an IP address, optionally with a port and IPv6 brackets, as in
+// RemoteAddr, Forwarded ("[2001:db8::1]:4711") and X-Forwarded-For.
+func parseAddr(value string) (netip.Addr, bool) {
+	value = strings.TrimSpace(value)
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	addr, err := netip.ParseAddr(strings.Trim(value, "[]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// ResolveClientIP resolves each request's client address once, for ClientIP. It should be
+// the first middleware, so that logging, rate limiting and analytics all agree on it.
+func ResolveClientIP(resolver *ClientIPResolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(clientIPContextKey, resolver.ClientIP(c.Request))
+		c.Next()
+	}
+}
+
+// ClientIP returns the address ResolveClientIP found, or the peer's address without it. Use
+// it instead of gin's c.ClientIP, which knows neither Forwarded nor the real IP header.
+func ClientIP(c *gin.Context) string {
+	if ip := c.GetString(clientIPContextKey); ip != "" {
+		return ip
+	}
+	return c.RemoteIP()
+}
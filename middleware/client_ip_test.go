@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientIPResolver(t *testing.T) {
+	resolver, err := NewClientIPResolver(ClientIPConfig{TrustedProxies: []string{"10.0.0.0/8", "2001:db8::1"}, RealIPHeader: "x-real-ip"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		remote  string
+		headers map[string][]string
+		want    string
+	}{
+		{"untrusted peer", "203.0.113.9:5000", map[string][]string{"X-Forwarded-For": {"198.51.100.1"}, "X-Real-Ip": {"198.51.100.2"}}, "203.0.113.9"},
+		{"trusted peer without headers", "10.0.0.2:5000", nil, "10.0.0.2"},
+		{"real IP header first", "10.0.0.2:5000", map[string][]string{"X-Real-Ip": {"198.51.100.2"}, "Forwarded": {"for=198.51.100.3"}}, "198.51.100.2"},
+		{"forwarded", "10.0.0.2:5000", map[string][]string{"Forwarded": {`for=192.0.2.60;proto=http;by=10.0.0.2`}}, "192.0.2.60"},
+		{"forwarded ipv6 with port", "[2001:db8::1]:443", map[string][]string{"Forwarded": {`For="[2001:db8:cafe::17]:4711"`}}, "2001:db8:cafe::17"},
+		{"forwarded over x-forwarded-for", "10.0.0.2:5000", map[string][]string{"Forwarded": {"for=192.0.2.60"}, "X-Forwarded-For": {"192.0.2.61"}}, "192.0.2.60"},
+		{"spoofed hops left of the client are ignored", "10.0.0.2:5000", map[string][]string{"X-Forwarded-For": {"1.2.3.4, 198.51.100.7", "10.1.1.1"}}, "198.51.100.7"},
+		{"forwarded chain across headers", "10.0.0.2:5000", map[string][]string{"Forwarded": {"for=1.2.3.4", "for=198.51.100.7, for=10.1.1.1"}}, "198.51.100.7"},
+		{"only trusted hops", "10.0.0.2:5000", map[string][]string{"X-Forwarded-For": {"10.3.3.3, 10.1.1.1"}}, "10.3.3.3"},
+		{"obfuscated hop", "10.0.0.2:5000", map[string][]string{"Forwarded": {"for=1.2.3.4, for=_hidden, for=10.1.1.1"}}, "_hidden"},
+		{"unknown hop", "10.0.0.2:5000", map[string][]string{"Forwarded": {"for=unknown"}}, "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			for name, values := range tt.headers {
+				req.Header[name] = values
+			}
+			assert.Equal(t, tt.want, resolver.ClientIP(req))
+		})
+	}
+
+	_, err = NewClientIPResolver(ClientIPConfig{TrustedProxies: []string{"10.0.0.0/33"}})
+	assert.Error(t, err)
+}
+
+func TestResolveClientIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	resolver, err := NewClientIPResolver(ClientIPConfig{})
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(ResolveClientIP(resolver))
+	router.GET("/", func(c *gin.Context) { c.String(http.StatusOK, ClientIP(c)) })
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.9:5000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "203.0.113.9", w.Body.String(), "no proxy is trusted by default")
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import "time"
+
+// RateLimit is a token bucket holding Burst requests, refilled at Requests per Window. A
+// client may make Burst requests at once, and then regains one every Window/Requests.
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
+	Burst    int // Default Requests
+}
+
+// interval is the time it takes to regain one request.
+func (l RateLimit) interval() time.Duration {
+	return l.Window / time.Duration(l.Requests)
+}
+
+func (l RateLimit) burst() int {
+	if l.Burst <= 0 {
+		return l.Requests
+	}
+	return l.Burst
+}
+
+// tolerance is how far ahead of now a client's arrival time may be: a full bucket's worth.
+func (l RateLimit) tolerance() time.Duration {
+	return time.Duration(l.burst()) * l.interval()
+}
+
+// RateLimitResult is the outcome of one request.
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int           // Requests the client could make right now
+	RetryAfter time.Duration // Until the next request would be allowed; 0 if this one was
+	ResetAfter time.Duration // Until the client has every request back
+}
+
+// RateLimitStore tracks clients' requests against their limits. Stores shared between
+// replicas enforce one limit across all of them.
+type RateLimitStore interface {
+	// Allow records a request by key if limit allows it.
+	Allow(key string, limit RateLimit) (RateLimitResult, error)
+}
+
+// gcra decides a request arriving at now for a client whose theoretical arrival time is tat,
+// no earlier than now, and returns the new arrival time if it is allowed.
+func gcra(now, tat time.Time, limit RateLimit) (RateLimitResult, time.Time) {
+	interval := limit.interval()
+	newTAT := tat.Add(interval)
+	allowAt := newTAT.Add(-limit.tolerance())
+	if now.Before(allowAt) {
+		return RateLimitResult{RetryAfter: allowAt.Sub(now), ResetAfter: tat.Sub(now)}, tat
+	}
+	return RateLimitResult{
+		Allowed:    true,
+		Remaining:  int(now.Sub(allowAt) / interval),
+		ResetAfter: newTAT.Sub(now),
+	}, newTAT
+}
@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"hash/maphash"
+	"sync"
+	"sync/atomic"
+	"time"
+	"url-shortener/logging"
+)
+
+// MemoryRateLimitConfig configures NewMemoryRateLimitStore. Zero values fall back to defaults.
+type MemoryRateLimitConfig struct {
+	// MaxVisitors caps how many clients are tracked, so a flood of addresses can't exhaust
+	// memory. Beyond it, new clients first make room by forgetting idle ones. Only if there
+	// are none do they evict arbitrary others, which start over with a full bucket.
+	// Default 100000.
+	MaxVisitors int
+	// Shards splits clients across this many locks, rounded up to a power of two. Default 64.
+	Shards int
+	// JanitorInterval is how often clients whose bucket is full again are forgotten. Default 1m.
+	JanitorInterval time.Duration
+}
+
+// MemoryRateLimitStats describes a MemoryRateLimitStore.
+type MemoryRateLimitStats struct {
+	Visitors int   `json:"visitors"`
+	Expired  int64 `json:"expired"` // Idle clients removed, by the janitor or to make room
+	Evicted  int64 `json:"evicted"` // Active clients removed to stay within MaxVisitors
+}
+
+// MemoryRateLimitStore keeps clients in process, so every replica limits them separately
+// and a restart forgets them. Clients are sharded by key so that concurrent requests rarely
+// wait for each other.
+type MemoryRateLimitStore struct {
+	shards    []visitorShard
+	seed      maphash.Seed
+	perShard  int
+	stop      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+
+	expired atomic.Int64
+	evicted atomic.Int64
+}
+
+type visitorShard struct {
+	mu       sync.Mutex
+	visitors map[string]time.Time // Theoretical arrival time of each client's next request
+}
+
+// NewMemoryRateLimitStore creates a store and starts its janitor; Close stops it.
+func NewMemoryRateLimitStore(config MemoryRateLimitConfig) *MemoryRateLimitStore {
+	if config.MaxVisitors <= 0 {
+		config.MaxVisitors = 100000
+	}
+	if config.Shards <= 0 {
+		config.Shards = 64
+	}
+	if config.JanitorInterval <= 0 {
+		config.JanitorInterval = time.Minute
+	}
+	shards := 1
+	for shards < config.Shards {
+		shards <<= 1
+	}
+
+	s := &MemoryRateLimitStore{
+		shards:   make([]visitorShard, shards),
+		seed:     maphash.MakeSeed(),
+		perShard: max(1, config.MaxVisitors/shards),
+		stop:     make(chan struct{}),
+	}
+	for i := range s.shards {
+		s.shards[i].visitors = make(map[string]time.Time)
+	}
+	s.wg.Add(1)
+	go s.janitor(config.JanitorInterval)
+	return s
+}
+
+// Allow implements the generic cell rate algorithm (GCRA): each client has a theoretical
+// arrival time that every request pushes one interval further. A request is allowed as long
+// as that time stays within a full bucket of now.
+func (s *MemoryRateLimitStore) Allow(key string, limit RateLimit) (RateLimitResult, error) {
+	shard := &s.shards[maphash.String(s.seed, key)&uint64(len(s.shards)-1)]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	now := time.Now()
+	tat, exists := shard.visitors[key]
+	if !exists || tat.Before(now) {
+		tat = now
+	}
+	result, newTAT := gcra(now, tat, limit)
+	if result.Allowed {
+		if !exists && len(shard.visitors) >= s.perShard {
+			s.makeRoom(shard, now)
+		}
+		shard.visitors[key] = newTAT
+	}
+	return result, nil
+}
+
+// Close stops the janitor.
+func (s *MemoryRateLimitStore) Close() {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		s.wg.Wait()
+	})
+}
+
+func (s *MemoryRateLimitStore) Stats() MemoryRateLimitStats {
+	stats := MemoryRateLimitStats{Expired: s.expired.Load(), Evicted: s.evicted.Load()}
+	for i := range s.shards {
+		shard := &s.shards[i]
+		shard.mu.Lock()
+		stats.Visitors += len(shard.visitors)
+		shard.mu.Unlock()
+	}
+	return stats
+}
+
+func (s *MemoryRateLimitStore) janitor(interval time.Duration) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if removed := s.removeIdle(time.Now()); removed > 0 {
+				logging.Log.WithField("visitors", removed).Debug("Rate limiter forgot idle clients")
+			}
+		}
+	}
+}
+
+// makeRoom frees a place in a full shard, which the caller has locked. Forgetting idle
+// clients costs nothing, so all of them go; otherwise one active client is evicted, since
+// keeping every throttled client would let a flood of addresses exhaust memory.
+func (s *MemoryRateLimitStore) makeRoom(shard *visitorShard, now time.Time) {
+	if removed := shard.removeIdle(now); removed > 0 {
+		s.expired.Add(int64(removed))
+		return
+	}
+	for evict := range shard.visitors { // Map order is random enough to pick a victim
+		delete(shard.visitors, evict)
+		s.evicted.Add(1)
+		return
+	}
+}
+
+// removeIdle forgets clients whose bucket is full again at now: they are no different from
+// clients never seen. It locks one shard at a time, so requests to the others go on.
+func (s *MemoryRateLimitStore) removeIdle(now time.Time) int {
+	removed := 0
+	for i := range s.shards {
+		shard := &s.shards[i]
+		shard.mu.Lock()
+		removed += shard.removeIdle(now)
+		shard.mu.Unlock()
+	}
+	s.expired.Add(int64(removed))
+	return removed
+}
+
+// removeIdle removes the shard's idle clients; the caller holds its lock.
+func (shard *visitorShard) removeIdle(now time.Time) int {
+	removed := 0
+	for key, tat := range shard.visitors {
+		if !tat.After(now) {
+			delete(shard.visitors, key)
+			removed++
+		}
+	}
+	return removed
+}
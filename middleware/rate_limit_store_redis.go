@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisRateLimitPrefix namespaces rate limit keys in a Redis shared with other data.
+const redisRateLimitPrefix = "url-shortener:ratelimit:"
+
+// gcraScript is memoryRateLimitStore.Allow in Lua, so that reading and pushing a client's
+// arrival time is atomic across replicas. Times are microseconds on the Redis clock, which
+// spares replicas from agreeing on theirs. It returns allowed (0 or 1), remaining, and the
+// retry and reset delays.
+var gcraScript = redis.NewScript(`
+local interval = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+local clock = redis.call("TIME")
+local now = tonumber(clock[1]) * 1000000 + tonumber(clock[2])
+
+local tat = tonumber(redis.call("GET", KEYS[1]))
+if not tat or tat < now then
+	tat = now
+end
+local new_tat = tat + interval
+local allow_at = new_tat - tolerance
+if now < allow_at then
+	return {0, 0, allow_at - now, tat - now}
+end
+redis.call("SET", KEYS[1], new_tat, "PX", math.ceil((new_tat - now) / 1000))
+return {1, math.floor((now - allow_at) / interval), 0, new_tat - now}
+`)
+
+// redisRateLimitStore keeps clients in Redis, so every replica enforces the same limit and
+// restarts don't reset it. Keys expire once the client has every request back.
+type redisRateLimitStore struct {
+	client *redis.Client
+}
+
+func NewRedisRateLimitStore(client *redis.Client) RateLimitStore {
+	return &redisRateLimitStore{client: client}
+}
+
+func (s *redisRateLimitStore) Allow(key string, limit RateLimit) (RateLimitResult, error) {
+	values, err := gcraScript.Run(context.Background(), s.client, []string{redisRateLimitPrefix + key},
+		limit.interval().Microseconds(), limit.tolerance().Microseconds()).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	return RateLimitResult{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Microsecond,
+		ResetAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
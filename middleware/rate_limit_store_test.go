@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func newTestMemoryStore(t testing.TB, config MemoryRateLimitConfig) *MemoryRateLimitStore {
+	store := NewMemoryRateLimitStore(config)
+	t.Cleanup(store.Close)
+	return store
+}
+
+func TestRateLimitStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) RateLimitStore{
+		"memory": func(t *testing.T) RateLimitStore { return newTestMemoryStore(t, MemoryRateLimitConfig{}) },
+		"redis":  func(t *testing.T) RateLimitStore { return NewRedisRateLimitStore(newTestRedis(t)) },
+	}
+	limit := RateLimit{Requests: 3, Window: time.Minute}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			for remaining := 2; remaining >= 0; remaining-- {
+				result, err := store.Allow("client", limit)
+				require.NoError(t, err)
+				assert.True(t, result.Allowed)
+				assert.Equal(t, remaining, result.Remaining)
+			}
+
+			result, err := store.Allow("client", limit)
+			require.NoError(t, err)
+			assert.False(t, result.Allowed)
+			assert.InDelta(t, 20*time.Second, result.RetryAfter, float64(time.Second))
+			assert.InDelta(t, time.Minute, result.ResetAfter, float64(time.Second))
+
+			result, err = store.Allow("other", limit)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed, "clients are limited separately")
+		})
+	}
+
+	t.Run("redis is shared between replicas", func(t *testing.T) {
+		client := newTestRedis(t)
+		first, second := NewRedisRateLimitStore(client), NewRedisRateLimitStore(client)
+		for i := 0; i < 3; i++ {
+			result, err := first.Allow("client", limit)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+		}
+		result, err := second.Allow("client", limit)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed)
+	})
+
+	t.Run("regains requests over time", func(t *testing.T) {
+		store := newTestMemoryStore(t, MemoryRateLimitConfig{})
+		fast := RateLimit{Requests: 2, Window: 100 * time.Millisecond}
+		for i := 0; i < 2; i++ {
+			result, _ := store.Allow("client", fast)
+			assert.True(t, result.Allowed)
+		}
+		result, _ := store.Allow("client", fast)
+		assert.False(t, result.Allowed)
+		time.Sleep(result.RetryAfter)
+		result, _ = store.Allow("client", fast)
+		assert.True(t, result.Allowed)
+	})
+}
+
+func TestMemoryRateLimitStoreBounds(t *testing.T) {
+	limit := RateLimit{Requests: 10, Window: time.Minute}
+
+	t.Run("forgets idle clients", func(t *testing.T) {
+		store := newTestMemoryStore(t, MemoryRateLimitConfig{JanitorInterval: 10 * time.Millisecond})
+		_, err := store.Allow("fast", RateLimit{Requests: 10, Window: 100 * time.Millisecond})
+		require.NoError(t, err)
+		_, err = store.Allow("slow", limit)
+		require.NoError(t, err)
+		assert.Equal(t, 2, store.Stats().Visitors)
+
+		assert.Eventually(t, func() bool { return store.Stats().Visitors == 1 }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, int64(1), store.Stats().Expired)
+	})
+
+	t.Run("caps tracked clients", func(t *testing.T) {
+		store := newTestMemoryStore(t, MemoryRateLimitConfig{MaxVisitors: 8, Shards: 3})
+		assert.Len(t, store.shards, 4)
+		for i := 0; i < 100; i++ {
+			result, err := store.Allow(fmt.Sprintf("client-%d", i), limit)
+			require.NoError(t, err)
+			assert.True(t, result.Allowed)
+		}
+		stats := store.Stats()
+		assert.LessOrEqual(t, stats.Visitors, 8)
+		assert.Equal(t, int64(100-stats.Visitors), stats.Evicted)
+	})
+
+	t.Run("makes room from idle clients first", func(t *testing.T) {
+		store := newTestMemoryStore(t, MemoryRateLimitConfig{MaxVisitors: 2, Shards: 1})
+		strict := RateLimit{Requests: 1, Window: time.Minute}
+		_, err := store.Allow("throttled", strict)
+		require.NoError(t, err)
+
+		// A flood of new clients whose buckets refill at once
+		brief := RateLimit{Requests: 1, Window: time.Millisecond}
+		for i := 0; i < 20; i++ {
+			_, err := store.Allow(fmt.Sprintf("flood-%d", i), brief)
+			require.NoError(t, err)
+			time.Sleep(2 * time.Millisecond)
+		}
+
+		result, err := store.Allow("throttled", strict)
+		require.NoError(t, err)
+		assert.False(t, result.Allowed, "the flood must not reset a throttled client")
+		stats := store.Stats()
+		assert.Zero(t, stats.Evicted)
+		assert.Positive(t, stats.Expired)
+	})
+}
+
+// BenchmarkMemoryRateLimitStore compares a single lock with the default sharding while many
+// goroutines limit many distinct clients.
+func BenchmarkMemoryRateLimitStore(b *testing.B) {
+	const clients = 100000
+	keys := make([]string, clients)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("ip:10.%d.%d.%d", i>>16&255, i>>8&255, i&255)
+	}
+	limit := RateLimit{Requests: 1000, Window: time.Minute}
+
+	for _, shards := range []int{1, 64} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			store := newTestMemoryStore(b, MemoryRateLimitConfig{Shards: shards, MaxVisitors: clients})
+			var next atomic.Int64
+			b.SetParallelism(8)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := int(next.Add(clients / 8))
+				for pb.Next() {
+					store.Allow(keys[i%clients], limit)
+					i++
+				}
+			})
+		})
+	}
+}
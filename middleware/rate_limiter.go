@@ -1,83 +1,80 @@
 package middleware
 
 import (
+	"fmt"
+	"math"
 	"net/http"
-	"os"
 	"strconv"
-	"sync"
 	"time"
 	"url-shortener/logging" // Added for logrus
 
 	"github.com/gin-gonic/gin"
-	"github.com/sirupsen/logrus" // Added for logrus fields
 )
 
-type visitor struct {
-	count    int
-	lastSeen time.Time
+// RateLimitPolicy limits one group of routes. Clients have a separate allowance in every
+// group, so following links doesn't use up what creating them needs, and vice versa.
+type RateLimitPolicy struct {
+	Group  string
+	PerIP  RateLimit // Clients without an API key, by IP address
+	PerKey RateLimit // Clients with an API key, by key, so keys behind one NAT don't share
 }
 
-var (
-	visitors       = make(map[string]*visitor)
-	mu             sync.Mutex
-	maxRequests    int
-	windowDuration time.Duration
-	// rateLimiterLogger = log.New(os.Stdout, "[RateLimiter] ", log.LstdFlags) // Removed
-)
-
-func init() {
-	var err error
-	maxRequestsStr := os.Getenv("MAX_REQUESTS_PER_MINUTE")
-	if maxRequests, err = strconv.Atoi(maxRequestsStr); err != nil {
-		maxRequests = 40 // default
-		logging.Log.WithError(err).WithField("value", maxRequestsStr).Warn("MAX_REQUESTS_PER_MINUTE defaulted")
-	}
-
-	windowSecondsStr := os.Getenv("RATE_LIMIT_WINDOW_SECONDS")
-	var windowSeconds int
-	if windowSeconds, err = strconv.Atoi(windowSecondsStr); err != nil {
-		windowSeconds = 60 // default
-		logging.Log.WithError(err).WithField("value", windowSecondsStr).Warn("RATE_LIMIT_WINDOW_SECONDS defaulted")
-	}
-	windowDuration = time.Second * time.Duration(windowSeconds)
-
-	logging.Log.WithFields(logrus.Fields{
-		"maxRequests":    maxRequests,
-		"windowDuration": windowDuration.String(),
-	}).Info("Loaded rate limiter configuration")
-}
-
-func RateLimiter() gin.HandlerFunc {
+// RateLimiter applies policy to each client, tracked in store. It identifies clients by API
+// key, so on routes that take one it must run after APIKeyAuth. Responses carry the
+// RateLimit-Limit, -Remaining, -Reset and -Policy headers, plus Retry-After once the limit is
+// reached. If the store fails, requests are let through rather than turning a store outage
+// into an API outage.
+func RateLimiter(store RateLimitStore, policy RateLimitPolicy) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		clientIP := c.ClientIP()
-		mu.Lock()
-		defer mu.Unlock() // Ensure mutex is always unlocked
+		key, limit := policy.Group+":ip:"+ClientIP(c), policy.PerIP
+		if apiKey := CurrentAPIKey(c); apiKey != nil {
+			key, limit = policy.Group+":key:"+strconv.FormatUint(uint64(apiKey.ID), 10), policy.PerKey
+		}
 
-		v, exists := visitors[clientIP]
-		if !exists {
-			visitors[clientIP] = &visitor{
-				count:    1,
-				lastSeen: time.Now(),
-			}
+		if enforce(c, store, key, limit) {
 			c.Next()
-			return
 		}
+	}
+}
 
-		// Reset count if window has passed
-		if time.Since(v.lastSeen) > windowDuration {
-			v.count = 1
-			v.lastSeen = time.Now()
-		} else if v.count >= maxRequests {
-			// mu.Unlock() // Not needed due to defer
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error": "Too many requests",
-			})
-			c.Abort()
+// AuthRateLimiter limits, per client IP, the requests that present an API key. It goes ahead
+// of APIKeyAuth, because checking a key costs a database lookup and a request with a wrong
+// key is rejected before any RateLimiter sees it; without it, keys could be guessed at any
+// rate. Requests without a key pass, as rejecting or serving them costs no lookup.
+func AuthRateLimiter(store RateLimitStore, limit RateLimit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiKeyFromRequest(c) != "" && !enforce(c, store, "auth:ip:"+ClientIP(c), limit) {
 			return
-		} else {
-			v.count++
 		}
-		v.lastSeen = time.Now() // Update lastSeen for every request that passes
 		c.Next()
 	}
 }
+
+// enforce records a request by key and sets the rate limit headers. It aborts the request
+// with 429 and reports false if the limit was reached.
+func enforce(c *gin.Context, store RateLimitStore, key string, limit RateLimit) bool {
+	result, err := store.Allow(key, limit)
+	if err != nil {
+		logging.Log.WithError(err).WithField("client", key).Warn("Rate limit store failed; allowing request")
+		return true
+	}
+
+	header := c.Writer.Header()
+	header.Set("RateLimit-Limit", strconv.Itoa(limit.burst()))
+	header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("RateLimit-Reset", seconds(result.ResetAfter))
+	header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%s;burst=%d", limit.Requests, seconds(limit.Window), limit.burst()))
+	if !result.Allowed {
+		header.Set("Retry-After", seconds(result.RetryAfter))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"error": "Too many requests",
+		})
+		return false
+	}
+	return true
+}
+
+// seconds formats d in whole seconds, rounded up so clients that wait that long succeed.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
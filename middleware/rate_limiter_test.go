@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"url-shortener/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingRateLimitStore stands in for an unreachable store.
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Allow(string, RateLimit) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("store unavailable")
+}
+
+func TestRateLimiter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newRouter := func(store RateLimitStore) *gin.Engine {
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			if id := c.GetHeader("X-Test-Key"); id != "" {
+				c.Set(apiKeyContextKey, &models.APIKey{ID: uint(len(id))})
+			}
+		})
+		redirects := RateLimiter(store, RateLimitPolicy{Group: "redirect", PerIP: RateLimit{Requests: 2, Window: time.Minute}})
+		creates := RateLimiter(store, RateLimitPolicy{
+			Group:  "create",
+			PerIP:  RateLimit{Requests: 1, Window: time.Minute},
+			PerKey: RateLimit{Requests: 60, Window: time.Minute, Burst: 2},
+		})
+		router.GET("/link", redirects, func(c *gin.Context) { c.Status(http.StatusOK) })
+		router.POST("/link", creates, func(c *gin.Context) { c.Status(http.StatusCreated) })
+		return router
+	}
+	serve := func(router *gin.Engine, method, key string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/link", nil)
+		if key != "" {
+			req.Header.Set("X-Test-Key", key)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("headers", func(t *testing.T) {
+		router := newRouter(newTestMemoryStore(t, MemoryRateLimitConfig{}))
+		w := serve(router, http.MethodGet, "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "30", w.Header().Get("RateLimit-Reset"))
+		assert.Equal(t, "2;w=60;burst=2", w.Header().Get("RateLimit-Policy"))
+		assert.Empty(t, w.Header().Get("Retry-After"))
+
+		serve(router, http.MethodGet, "")
+		w = serve(router, http.MethodGet, "")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("groups and clients are limited separately", func(t *testing.T) {
+		router := newRouter(newTestMemoryStore(t, MemoryRateLimitConfig{}))
+		assert.Equal(t, http.StatusOK, serve(router, http.MethodGet, "").Code)
+		assert.Equal(t, http.StatusOK, serve(router, http.MethodGet, "").Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve(router, http.MethodGet, "").Code)
+
+		assert.Equal(t, http.StatusCreated, serve(router, http.MethodPost, "").Code, "redirects don't use up creates")
+		assert.Equal(t, http.StatusTooManyRequests, serve(router, http.MethodPost, "").Code)
+
+		// Keys behind the same IP have their own buckets, with their own limit
+		for _, key := range []string{"a", "a", "bb"} {
+			w := serve(router, http.MethodPost, key)
+			assert.Equal(t, http.StatusCreated, w.Code)
+			assert.Equal(t, "60;w=60;burst=2", w.Header().Get("RateLimit-Policy"))
+		}
+		assert.Equal(t, http.StatusTooManyRequests, serve(router, http.MethodPost, "a").Code)
+	})
+
+	t.Run("store failures let requests through", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve(newRouter(failingRateLimitStore{}), http.MethodGet, "").Code)
+	})
+}
+
+// countingAuthenticator rejects every key, counting the lookups.
+type countingAuthenticator struct{ lookups int }
+
+func (a *countingAuthenticator) Authenticate(string) (*models.APIKey, error) {
+	a.lookups++
+	return nil, errors.New("unknown key")
+}
+
+func TestAuthRateLimiter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authenticator := &countingAuthenticator{}
+	router := gin.New()
+	router.GET("/links", AuthRateLimiter(newTestMemoryStore(t, MemoryRateLimitConfig{}), RateLimit{Requests: 3, Window: time.Minute}),
+		APIKeyAuth(authenticator, true), func(c *gin.Context) { c.Status(http.StatusOK) })
+	serve := func(key string) int {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/links", nil)
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusUnauthorized, serve("guess"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, serve("another-guess"))
+	assert.Equal(t, 3, authenticator.lookups, "rejected before the key is looked up")
+	assert.Equal(t, http.StatusUnauthorized, serve(""), "requests without a key cost no lookup and aren't limited")
+}
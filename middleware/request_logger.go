@@ -24,7 +24,7 @@ func RequestLogger() gin.HandlerFunc {
 			"path":      c.Request.URL.Path,
 			"status":    statusCode,
 			"latency":   latency.String(),
-			"client_ip": c.ClientIP(),
+			"client_ip": ClientIP(c),
 			"user_agent": c.Request.UserAgent(),
 		})
 
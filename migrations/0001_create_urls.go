@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// urlV1 is the urls table as originally created by AutoMigrate. Migrations use frozen
+// snapshots like this one rather than models.URL, which keeps changing.
+type urlV1 struct {
+	gorm.Model
+	OriginalURL    string    `gorm:"type:text;not null"`
+	ShortLink      string    `gorm:"type:varchar(10);unique;not null"`
+	ExpirationDate time.Time `gorm:"not null"`
+}
+
+func (urlV1) TableName() string {
+	return "urls"
+}
+
+var createURLs = Migration{
+	Version: 1,
+	Name:    "create_urls",
+	Up: func(tx *gorm.DB) error {
+		// Databases created before migrations existed already have this table from AutoMigrate.
+		if tx.Migrator().HasTable(&urlV1{}) {
+			return nil
+		}
+		return tx.Migrator().CreateTable(&urlV1{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&urlV1{})
+	},
+}
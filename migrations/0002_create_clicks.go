@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type clickV1 struct {
+	ID        uint      `gorm:"primaryKey"`
+	URLID     uint      `gorm:"not null;index:idx_clicks_url_clicked_at,priority:1"`
+	ClickedAt time.Time `gorm:"not null;index:idx_clicks_url_clicked_at,priority:2"`
+	Referrer  string    `gorm:"type:varchar(2048)"`
+	UserAgent string    `gorm:"type:varchar(512)"`
+	IPHash    string    `gorm:"type:varchar(64)"`
+	Country   string    `gorm:"type:varchar(8)"`
+}
+
+func (clickV1) TableName() string {
+	return "clicks"
+}
+
+var createClicks = Migration{
+	Version: 2,
+	Name:    "create_clicks",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&clickV1{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&clickV1{})
+	},
+}
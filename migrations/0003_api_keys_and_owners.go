@@ -0,0 +1,54 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type apiKeyV1 struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"type:varchar(100);not null"`
+	Prefix    string `gorm:"type:varchar(16);not null"`
+	KeyHash   string `gorm:"type:char(64);uniqueIndex;not null"`
+	IsAdmin   bool   `gorm:"not null;default:false"`
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+func (apiKeyV1) TableName() string {
+	return "api_keys"
+}
+
+// urlV2 adds the owning API key to urlV1.
+type urlV2 struct {
+	urlV1
+	OwnerID *uint `gorm:"index"`
+}
+
+func (urlV2) TableName() string {
+	return "urls"
+}
+
+var createAPIKeysAndOwners = Migration{
+	Version: 3,
+	Name:    "create_api_keys_and_url_owners",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.Migrator().CreateTable(&apiKeyV1{}); err != nil {
+			return err
+		}
+		if err := tx.Migrator().AddColumn(&urlV2{}, "OwnerID"); err != nil {
+			return err
+		}
+		return tx.Migrator().CreateIndex(&urlV2{}, "OwnerID")
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropIndex(&urlV2{}, "OwnerID"); err != nil {
+			return err
+		}
+		if err := tx.Migrator().DropColumn(&urlV2{}, "OwnerID"); err != nil {
+			return err
+		}
+		return tx.Migrator().DropTable(&apiKeyV1{})
+	},
+}
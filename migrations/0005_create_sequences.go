@@ -0,0 +1,23 @@
+package migrations
+
+import "gorm.io/gorm"
+
+type sequenceV1 struct {
+	Name      string `gorm:"type:varchar(64);primaryKey"`
+	NextValue int64  `gorm:"not null"`
+}
+
+func (sequenceV1) TableName() string {
+	return "sequences"
+}
+
+var createSequences = Migration{
+	Version: 5,
+	Name:    "create_sequences",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&sequenceV1{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&sequenceV1{})
+	},
+}
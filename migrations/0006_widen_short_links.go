@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// widenShortLinks makes room for word slugs such as brave-otter-42. SQLite doesn't enforce
+// varchar lengths, so only MySQL needs the ALTER; it keeps the unique index.
+var widenShortLinks = Migration{
+	Version: 6,
+	Name:    "widen_url_short_link",
+	Up: func(tx *gorm.DB) error {
+		return resizeShortLink(tx, 32)
+	},
+	Down: func(tx *gorm.DB) error {
+		// Fails on MySQL while links longer than 10 characters exist
+		return resizeShortLink(tx, 10)
+	},
+}
+
+func resizeShortLink(tx *gorm.DB, width int) error {
+	if tx.Dialector.Name() != "mysql" {
+		return nil
+	}
+	return tx.Exec(fmt.Sprintf("ALTER TABLE urls MODIFY short_link varchar(%d) NOT NULL", width)).Error
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type reservedSlugV1 struct {
+	ID        uint   `gorm:"primaryKey"`
+	Value     string `gorm:"type:varchar(255);uniqueIndex;not null"`
+	IsPattern bool   `gorm:"not null;default:false"`
+	Note      string `gorm:"type:varchar(255)"`
+	CreatedAt time.Time
+}
+
+func (reservedSlugV1) TableName() string {
+	return "reserved_slugs"
+}
+
+var createReservedSlugs = Migration{
+	Version: 7,
+	Name:    "create_reserved_slugs",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().CreateTable(&reservedSlugV1{})
+	},
+	Down: func(tx *gorm.DB) error {
+		return tx.Migrator().DropTable(&reservedSlugV1{})
+	},
+}
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// urlV4 adds the time before which a link doesn't resolve.
+type urlV4 struct {
+	urlV3
+	ActivatesAt *time.Time `gorm:"index"`
+}
+
+func (urlV4) TableName() string {
+	return "urls"
+}
+
+var addURLActivatesAt = Migration{
+	Version: 8,
+	Name:    "add_url_activates_at",
+	Up: func(tx *gorm.DB) error {
+		if err := tx.Migrator().AddColumn(&urlV4{}, "ActivatesAt"); err != nil {
+			return err
+		}
+		return tx.Migrator().CreateIndex(&urlV4{}, "ActivatesAt")
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := tx.Migrator().DropIndex(&urlV4{}, "ActivatesAt"); err != nil {
+			return err
+		}
+		return dropColumn(tx, "urls", "activates_at")
+	},
+}
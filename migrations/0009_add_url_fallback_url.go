@@ -0,0 +1,24 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// urlV5 adds the destination used once a link has expired.
+type urlV5 struct {
+	urlV4
+	FallbackURL string `gorm:"type:text"`
+}
+
+func (urlV5) TableName() string {
+	return "urls"
+}
+
+var addURLFallbackURL = Migration{
+	Version: 9,
+	Name:    "add_url_fallback_url",
+	Up: func(tx *gorm.DB) error {
+		return tx.Migrator().AddColumn(&urlV5{}, "FallbackURL")
+	},
+	Down: func(tx *gorm.DB) error {
+		return dropColumn(tx, "urls", "fallback_url")
+	},
+}
@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"url-shortener/utils"
+
+	"gorm.io/gorm"
+)
+
+// clickV2 stores the referrer host and user agent family that stats group clicks by.
+type clickV2 struct {
+	clickV1
+	ReferrerHost    string `gorm:"type:varchar(255)"`
+	UserAgentFamily string `gorm:"type:varchar(32)"`
+}
+
+func (clickV2) TableName() string {
+	return "clicks"
+}
+
+var addClickDimensions = Migration{
+	Version: 10,
+	Name:    "add_click_dimensions",
+	Up: func(tx *gorm.DB) error {
+		for _, column := range []string{"ReferrerHost", "UserAgentFamily"} {
+			if err := tx.Migrator().AddColumn(&clickV2{}, column); err != nil {
+				return err
+			}
+		}
+		// Existing clicks are classified once per distinct value rather than once per click
+		if err := backfillClicks(tx, "referrer", "referrer_host", utils.ReferrerHost); err != nil {
+			return err
+		}
+		return backfillClicks(tx, "user_agent", "user_agent_family", utils.UserAgentFamily)
+	},
+	Down: func(tx *gorm.DB) error {
+		if err := dropColumn(tx, "clicks", "user_agent_family"); err != nil {
+			return err
+		}
+		return dropColumn(tx, "clicks", "referrer_host")
+	},
+}
+
+// backfillClicks sets column to classify(source) on every click.
+func backfillClicks(tx *gorm.DB, source, column string, classify func(string) string) error {
+	var values []string
+	if err := tx.Model(&clickV2{}).Distinct(source).Pluck(source, &values).Error; err != nil {
+		return err
+	}
+	for _, value := range values {
+		if err := tx.Model(&clickV2{}).Where(source+" = ?", value).Update(column, classify(value)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
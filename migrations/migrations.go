@@ -0,0 +1,314 @@
+// Package migrations applies numbered, reversible schema changes and records them
+// in the schema_migrations table.
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+	"url-shortener/logging"
+	"url-shortener/models"
+	"url-shortener/repositories"
+	"url-shortener/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// lockName is the lease held while migrations run so that replicas booting together
+	// don't apply the same migration twice.
+	lockName    = "schema_migrations"
+	lockTTL     = 5 * time.Minute
+	lockTimeout = 2 * time.Minute
+	lockPoll    = 500 * time.Millisecond
+)
+
+var (
+	// ErrLockTimeout is returned when another instance holds the migration lock for too long.
+	ErrLockTimeout = errors.New("timed out waiting for migration lock")
+	// ErrLockLost is returned when the migration lock couldn't be renewed, so another
+	// instance may be migrating too. Migrations stop before the next one starts.
+	ErrLockLost = errors.New("lost migration lock")
+)
+
+// Migration is a single schema change. Up and Down run inside a transaction where the
+// database supports transactional DDL.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// all lists every migration in order. Append new migrations here; never renumber.
+var all = []Migration{
+	createURLs,
+	createClicks,
+	createAPIKeysAndOwners,
+	addURLListingIndexes,
+	createSequences,
+	widenShortLinks,
+	createReservedSlugs,
+	addURLActivatesAt,
+	addURLFallbackURL,
+	addClickDimensions,
+}
+
+// schemaMigration records an applied migration.
+type schemaMigration struct {
+	Version   int    `gorm:"primaryKey;autoIncrement:false"`
+	Name      string `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status describes whether a migration has been applied.
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	locks      repositories.LockRepository
+	owner      string
+	lockTTL    time.Duration
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB) *Migrator {
+	migrations := make([]Migration, len(all))
+	copy(migrations, all)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return &Migrator{db: db, locks: repositories.NewLockRepository(db), owner: utils.InstanceID(), lockTTL: lockTTL, migrations: migrations}
+}
+
+// Up applies all pending migrations and returns the ones it ran.
+func (m *Migrator) Up() ([]Migration, error) {
+	var ran []Migration
+	err := m.withLock(func(applied map[int]schemaMigration, held *lease) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := held.check(); err != nil {
+				return err
+			}
+			done, err := m.apply(migration)
+			if err != nil {
+				return err
+			}
+			if done {
+				ran = append(ran, migration)
+			}
+		}
+		return nil
+	})
+	return ran, err
+}
+
+// Down rolls back the most recently applied migrations, up to steps of them.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var ran []Migration
+	err := m.withLock(func(applied map[int]schemaMigration, held *lease) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(ran) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := held.check(); err != nil {
+				return err
+			}
+			done, err := m.revert(migration)
+			if err != nil {
+				return err
+			}
+			if done {
+				ran = append(ran, migration)
+			}
+		}
+		return nil
+	})
+	return ran, err
+}
+
+// Status reports every known migration and whether it has been applied.
+func (m *Migrator) Status() ([]Status, error) {
+	if err := m.ensureBookkeeping(); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		record, ok := applied[migration.Version]
+		statuses = append(statuses, Status{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Applied:   ok,
+			AppliedAt: record.AppliedAt,
+		})
+	}
+	return statuses, nil
+}
+
+// apply runs migration unless schema_migrations shows it was applied after withLock read it,
+// and reports whether it ran.
+func (m *Migrator) apply(migration Migration) (bool, error) {
+	ran := false
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if recorded, err := isRecorded(tx, migration.Version); err != nil || recorded {
+			return err
+		}
+		logging.Log.WithField("version", migration.Version).Infof("Applying migration %s", migration.Name)
+		if err := migration.Up(tx); err != nil {
+			return fmt.Errorf("migration %d (%s) up: %w", migration.Version, migration.Name, err)
+		}
+		ran = true
+		return tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+	})
+	return ran && err == nil, err
+}
+
+// revert rolls migration back unless it was reverted after withLock read schema_migrations,
+// and reports whether it ran.
+func (m *Migrator) revert(migration Migration) (bool, error) {
+	ran := false
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if recorded, err := isRecorded(tx, migration.Version); err != nil || !recorded {
+			return err
+		}
+		logging.Log.WithField("version", migration.Version).Infof("Reverting migration %s", migration.Name)
+		if err := migration.Down(tx); err != nil {
+			return fmt.Errorf("migration %d (%s) down: %w", migration.Version, migration.Name, err)
+		}
+		ran = true
+		return tx.Delete(&schemaMigration{Version: migration.Version}).Error
+	})
+	return ran && err == nil, err
+}
+
+func isRecorded(tx *gorm.DB, version int) (bool, error) {
+	var count int64
+	err := tx.Model(&schemaMigration{}).Where("version = ?", version).Count(&count).Error
+	return count > 0, err
+}
+
+// lease is the migration lock held by withLock, renewed in the background while it runs.
+type lease struct {
+	lost atomic.Bool
+}
+
+// check fails once a renewal failed, since another instance may hold the lock by now.
+func (l *lease) check() error {
+	if l.lost.Load() {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// withLock holds the migration lease while fn runs, passing it the applied migrations
+// as read after the lease was taken. The lease is renewed every third of its TTL, so long
+// migrations don't outlive it; fn should check it between steps.
+func (m *Migrator) withLock(fn func(applied map[int]schemaMigration, held *lease) error) error {
+	if err := m.ensureBookkeeping(); err != nil {
+		return err
+	}
+
+	owner := m.owner
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		acquired, err := m.locks.TryAcquire(lockName, owner, m.lockTTL)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			break
+		}
+		if time.Now().After(deadline) {
+			return ErrLockTimeout
+		}
+		logging.Log.Info("Waiting for another instance to finish migrating")
+		time.Sleep(lockPoll)
+	}
+	held := &lease{}
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	go m.renew(held, stop, stopped)
+	defer func() {
+		close(stop)
+		<-stopped
+		if err := m.locks.Release(lockName, owner); err != nil {
+			logging.Log.WithError(err).Warn("Failed to release migration lock")
+		}
+	}()
+
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+	return fn(applied, held)
+}
+
+// renew extends the lease until stop is closed. The first failure marks it lost for good:
+// even if a later renewal succeeded, another instance could have held the lock in between.
+func (m *Migrator) renew(held *lease, stop <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	ticker := time.NewTicker(m.lockTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			acquired, err := m.locks.TryAcquire(lockName, m.owner, m.lockTTL)
+			if err != nil || !acquired {
+				logging.Log.WithError(err).Error("Failed to renew migration lock")
+				held.lost.Store(true)
+				return
+			}
+		}
+	}
+}
+
+func (m *Migrator) applied() (map[int]schemaMigration, error) {
+	var records []schemaMigration
+	if err := m.db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]schemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// ensureBookkeeping creates the schema_migrations and locks tables. These are the only
+// tables managed outside of numbered migrations, since the migrator itself depends on them.
+func (m *Migrator) ensureBookkeeping() error {
+	for _, table := range []interface{}{&schemaMigration{}, &models.Lock{}} {
+		if m.db.Migrator().HasTable(table) {
+			continue
+		}
+		// Another instance may create the table concurrently, so re-check before failing.
+		if err := m.db.Migrator().CreateTable(table); err != nil && !m.db.Migrator().HasTable(table) {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropColumn uses plain ALTER TABLE ... DROP COLUMN, supported by MySQL and SQLite 3.35+.
+// gorm's SQLite migrator instead rebuilds the table, which discards its other indexes.
+func dropColumn(tx *gorm.DB, table, column string) error {
+	return tx.Exec("ALTER TABLE " + table + " DROP COLUMN " + column).Error
+}
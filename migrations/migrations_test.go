@@ -0,0 +1,204 @@
+package migrations
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+	"url-shortener/models"
+	"url-shortener/repositories"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	return db
+}
+
+func TestUpStatusDown(t *testing.T) {
+	db := newTestDB(t)
+	migrator := NewMigrator(db)
+
+	applied, err := migrator.Up()
+	require.NoError(t, err)
+	assert.Len(t, applied, len(all))
+	assert.True(t, db.Migrator().HasTable("urls"))
+
+	// A second run is a no-op
+	applied, err = migrator.Up()
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	// Even when called directly, since apply re-checks schema_migrations
+	ran, err := migrator.apply(migrator.migrations[0])
+	require.NoError(t, err)
+	assert.False(t, ran)
+
+	statuses, err := migrator.Status()
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.True(t, status.Applied, "migration %d should be applied", status.Version)
+	}
+
+	reverted, err := migrator.Down(len(all))
+	require.NoError(t, err)
+	assert.Len(t, reverted, len(all))
+	assert.False(t, db.Migrator().HasTable("urls"))
+}
+
+func TestConcurrentUpAppliesOnce(t *testing.T) {
+	db := newTestDB(t)
+
+	var wg sync.WaitGroup
+	results := make([][]Migration, 3)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			migrator := NewMigrator(db)
+			migrator.owner = fmt.Sprintf("replica-%d", i) // Simulate separate instances
+			var err error
+			results[i], err = migrator.Up()
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	total := 0
+	for _, ran := range results {
+		total += len(ran)
+	}
+	assert.Equal(t, len(all), total)
+
+	var count int64
+	db.Model(&schemaMigration{}).Count(&count)
+	assert.Equal(t, int64(len(all)), count)
+}
+
+// TestSchemaMatchesModels guards against model fields that no migration creates.
+func TestLockRenewedDuringLongMigration(t *testing.T) {
+	db := newTestDB(t)
+	migrator := NewMigrator(db)
+	migrator.lockTTL = 60 * time.Millisecond
+	slow := Migration{Version: 1, Name: "slow", Up: func(tx *gorm.DB) error {
+		time.Sleep(4 * migrator.lockTTL)
+		return nil
+	}}
+	migrator.migrations = []Migration{slow}
+
+	done := make(chan error)
+	go func() {
+		_, err := migrator.Up()
+		done <- err
+	}()
+	time.Sleep(2 * migrator.lockTTL) // Past the TTL the lease was first taken with
+	acquired, err := repositories.NewLockRepository(db).TryAcquire(lockName, "replica-2", migrator.lockTTL)
+	require.NoError(t, err)
+	assert.False(t, acquired, "the lease must still be held")
+	require.NoError(t, <-done)
+}
+
+// failingRenewals grants the first lease and refuses to renew it.
+type failingRenewals struct {
+	repositories.LockRepository
+	calls int
+}
+
+func (l *failingRenewals) TryAcquire(name, owner string, ttl time.Duration) (bool, error) {
+	l.calls++
+	return l.calls == 1, nil
+}
+
+func TestLostLockStopsMigrations(t *testing.T) {
+	db := newTestDB(t)
+	migrator := NewMigrator(db)
+	migrator.lockTTL = 30 * time.Millisecond
+	migrator.locks = &failingRenewals{LockRepository: migrator.locks}
+	slow := func(tx *gorm.DB) error {
+		time.Sleep(2 * migrator.lockTTL)
+		return nil
+	}
+	migrator.migrations = []Migration{{Version: 1, Name: "first", Up: slow}, {Version: 2, Name: "second", Up: slow}}
+
+	ran, err := migrator.Up()
+	assert.ErrorIs(t, err, ErrLockLost)
+	assert.Len(t, ran, 1)
+}
+
+func TestSchemaMatchesModels(t *testing.T) {
+	db := newTestDB(t)
+	_, err := NewMigrator(db).Up()
+	require.NoError(t, err)
+
+	for _, model := range []interface{}{&models.URL{}, &models.Click{}, &models.APIKey{}, &models.Sequence{}, &models.ReservedSlug{}} {
+		stmt := &gorm.Statement{DB: db}
+		require.NoError(t, stmt.Parse(model))
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			assert.True(t, db.Migrator().HasColumn(model, field.DBName), "%s.%s has no column", stmt.Schema.Table, field.DBName)
+		}
+	}
+}
+
+func TestDestinationHostBackfill(t *testing.T) {
+	db := newTestDB(t)
+	migrator := NewMigrator(db)
+	_, err := migrator.Up()
+	require.NoError(t, err)
+
+	// Roll back to just before migration 4 and insert a link as an older release would
+	_, err = migrator.Down(len(all) - addURLListingIndexes.Version + 1)
+	require.NoError(t, err)
+	require.NoError(t, db.Exec("INSERT INTO urls (original_url, short_link, expiration_date) VALUES (?, ?, ?)",
+		"https://Docs.Example.com:8443/path", "old1", "2030-01-01 00:00:00").Error)
+	// Enough links for more than one batch
+	for i := 0; i < backfillBatchSize; i++ {
+		require.NoError(t, db.Exec("INSERT INTO urls (original_url, short_link, expiration_date) VALUES (?, ?, ?)",
+			fmt.Sprintf("https://host%d.example/", i), fmt.Sprintf("bulk%d", i), "2030-01-01 00:00:00").Error)
+	}
+
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	var host string
+	require.NoError(t, db.Raw("SELECT destination_host FROM urls WHERE short_link = ?", "old1").Scan(&host).Error)
+	assert.Equal(t, "docs.example.com", host)
+	require.NoError(t, db.Raw("SELECT destination_host FROM urls WHERE short_link = ?", fmt.Sprintf("bulk%d", backfillBatchSize-1)).Scan(&host).Error)
+	assert.Equal(t, fmt.Sprintf("host%d.example", backfillBatchSize-1), host)
+}
+
+func TestClickDimensionsBackfill(t *testing.T) {
+	db := newTestDB(t)
+	migrator := NewMigrator(db)
+	_, err := migrator.Up()
+	require.NoError(t, err)
+
+	_, err = migrator.Down(len(all) - addClickDimensions.Version + 1)
+	require.NoError(t, err)
+	require.NoError(t, db.Exec("INSERT INTO clicks (url_id, clicked_at, referrer, user_agent) VALUES (?, ?, ?, ?), (?, ?, ?, ?)",
+		1, "2030-01-01 00:00:00", "https://www.Example.com/page", "Mozilla/5.0 Firefox/125.0",
+		1, "2030-01-01 00:00:00", "", "").Error)
+
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	var clicks []models.Click
+	require.NoError(t, db.Order("id").Find(&clicks).Error)
+	require.Len(t, clicks, 2)
+	assert.Equal(t, "example.com", clicks[0].ReferrerHost)
+	assert.Equal(t, "Firefox", clicks[0].UserAgentFamily)
+	assert.Equal(t, "direct", clicks[1].ReferrerHost)
+	assert.Equal(t, "Unknown", clicks[1].UserAgentFamily)
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// APIKey authenticates API clients. Only the SHA-256 hash of the key is stored; the
+// plaintext is shown once when the key is created.
+type APIKey struct {
+	ID        uint   `gorm:"primaryKey"`
+	Name      string `gorm:"type:varchar(100);not null"`
+	Prefix    string `gorm:"type:varchar(16);not null"` // Leading characters of the key, for identification
+	KeyHash   string `gorm:"type:char(64);uniqueIndex;not null"`
+	IsAdmin   bool   `gorm:"not null;default:false"`
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
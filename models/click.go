@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Click is a single redirect of a short link. The client IP is only ever stored hashed.
+type Click struct {
+	ID        uint      `gorm:"primaryKey"`
+	URLID     uint      `gorm:"not null;index:idx_clicks_url_clicked_at,priority:1"`
+	ClickedAt time.Time `gorm:"not null;index:idx_clicks_url_clicked_at,priority:2"`
+	Referrer  string    `gorm:"type:varchar(2048)"`
+	UserAgent string    `gorm:"type:varchar(512)"`
+	IPHash    string    `gorm:"type:varchar(64)"`
+	Country   string    `gorm:"type:varchar(8)"`
+	// ReferrerHost and UserAgentFamily classify Referrer and UserAgent when the click is
+	// recorded, so that stats can be grouped by them in the database.
+	ReferrerHost    string `gorm:"type:varchar(255)"`
+	UserAgentFamily string `gorm:"type:varchar(32)"`
+}
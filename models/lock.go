@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Lock is a named lease held in the database so that only one instance performs
+// a given task (running migrations, sweeping, ...) at a time.
+type Lock struct {
+	Name      string    `gorm:"type:varchar(64);primaryKey"`
+	Owner     string    `gorm:"type:varchar(128);not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// ReservedSlug is a word or pattern that custom and generated slugs may not use, added
+// through the admin API.
+type ReservedSlug struct {
+	ID        uint   `gorm:"primaryKey"`
+	Value     string `gorm:"type:varchar(255);uniqueIndex;not null"` // Lower-cased word, or a regular expression
+	IsPattern bool   `gorm:"not null;default:false"`
+	Note      string `gorm:"type:varchar(255)"`
+	CreatedAt time.Time
+}
@@ -0,0 +1,8 @@
+package models
+
+// Sequence is a named counter. Instances lease blocks of values from it, so values are
+// unique across replicas without a round trip per value.
+type Sequence struct {
+	Name      string `gorm:"type:varchar(64);primaryKey"`
+	NextValue int64  `gorm:"not null"` // First value not yet leased
+}
@@ -6,9 +6,29 @@ import (
 	"gorm.io/gorm"
 )
 
+// NeverExpires is the expiration date of permanent links. A far-future date rather than NULL
+// keeps expiry filters, sorting and sweeping the same for every link.
+var NeverExpires = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// URL is a short link. created_at is additionally indexed (idx_urls_created_at) by
+// migration 4, since gorm.Model's fields can't carry extra tags.
 type URL struct {
 	gorm.Model
-	OriginalURL    string    `gorm:"type:text;not null"`
-	ShortLink      string    `gorm:"type:varchar(10);unique;not null"`
-	ExpirationDate time.Time `gorm:"not null"`
+	OriginalURL     string     `gorm:"type:text;not null"`
+	ShortLink       string     `gorm:"type:varchar(32);unique;not null"`
+	ExpirationDate  time.Time  `gorm:"not null;index:idx_urls_expiration_date"`
+	OwnerID         *uint      `gorm:"index"`                                             // API key that created the link; nil for anonymous links
+	DestinationHost string     `gorm:"type:varchar(255);index:idx_urls_destination_host"` // Lower-cased host of OriginalURL, for domain filters
+	ActivatesAt     *time.Time `gorm:"index"`                                             // The link doesn't resolve before this; nil if live from creation
+	FallbackURL     string     `gorm:"type:text"`                                         // Where visitors go once the link expired or was deleted; "" for the global fallback
+}
+
+// Expires reports whether the link has an expiration date, i.e. isn't permanent.
+func (u *URL) Expires() bool {
+	return u.ExpirationDate.Before(NeverExpires)
+}
+
+// IsActive reports whether the link has reached its activation time at now.
+func (u *URL) IsActive(now time.Time) bool {
+	return u.ActivatesAt == nil || !u.ActivatesAt.After(now)
 }
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"url-shortener/models"
+
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	Create(key *models.APIKey) error
+	FindByHash(keyHash string) (*models.APIKey, error)
+	FindAll() ([]models.APIKey, error)
+	Revoke(id uint, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(key *models.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *apiKeyRepository) FindByHash(keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) FindAll() ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.Order("id").Find(&keys).Error
+	return keys, err
+}
+
+func (r *apiKeyRepository) Revoke(id uint, at time.Time) error {
+	result := r.db.Model(&models.APIKey{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
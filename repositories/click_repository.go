@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"time"
+	"url-shortener/models"
+
+	"gorm.io/gorm"
+)
+
+// ClickSummary aggregates the clicks of one URL over a time range.
+type ClickSummary struct {
+	Clicks         int
+	UniqueVisitors int // Distinct IP hashes
+	Hourly         []HourlyClicks
+	TopReferrers   []ValueCount // By ReferrerHost
+	TopUserAgents  []ValueCount // By UserAgentFamily
+	TopCountries   []ValueCount // Clicks without a country aren't counted
+}
+
+// HourlyClicks counts the clicks in the hour starting at Hour. Hours without clicks are left
+// out.
+type HourlyClicks struct {
+	Hour   time.Time
+	Clicks int
+}
+
+// ValueCount is one entry of a top list: highest counts first, ties broken alphabetically.
+type ValueCount struct {
+	Value string
+	Count int
+}
+
+type ClickRepository interface {
+	Create(click *models.Click) error
+	CreateBatch(clicks []models.Click) error
+	// SummarizeByURLID aggregates the clicks for a URL in [from, to), keeping top entries
+	// in each top list. Hourly is oldest first.
+	SummarizeByURLID(urlID uint, from, to time.Time, top int) (*ClickSummary, error)
+	CountByURLID(urlID uint) (int64, error)
+	// DeleteByURLIDs removes the clicks of purged links and returns how many there were.
+	DeleteByURLIDs(urlIDs []uint) (int64, error)
+}
+
+type clickRepository struct {
+	db *gorm.DB
+}
+
+func NewClickRepository(db *gorm.DB) ClickRepository {
+	return &clickRepository{db: db}
+}
+
+func (r *clickRepository) Create(click *models.Click) error {
+	return r.db.Create(click).Error
+}
+
+func (r *clickRepository) CreateBatch(clicks []models.Click) error {
+	if len(clicks) == 0 {
+		return nil
+	}
+	return r.db.Create(&clicks).Error
+}
+
+func (r *clickRepository) SummarizeByURLID(urlID uint, from, to time.Time, top int) (*ClickSummary, error) {
+	inRange := func() *gorm.DB {
+		return r.db.Model(&models.Click{}).Where("url_id = ? AND clicked_at >= ? AND clicked_at < ?", urlID, from, to)
+	}
+
+	summary := &ClickSummary{}
+	var totals struct {
+		Clicks   int
+		Visitors int
+	}
+	if err := inRange().Select("COUNT(*) AS clicks, COUNT(DISTINCT ip_hash) AS visitors").Scan(&totals).Error; err != nil {
+		return nil, err
+	}
+	summary.Clicks, summary.UniqueVisitors = totals.Clicks, totals.Visitors
+	if summary.Clicks == 0 {
+		return summary, nil
+	}
+
+	var hours []struct {
+		Bucket string
+		Clicks int
+	}
+	if err := inRange().Select(r.hourExpression() + " AS bucket, COUNT(*) AS clicks").Group("bucket").Order("bucket").Scan(&hours).Error; err != nil {
+		return nil, err
+	}
+	for _, hour := range hours {
+		start, err := time.ParseInLocation(time.DateTime, hour.Bucket, r.location())
+		if err != nil {
+			return nil, err
+		}
+		summary.Hourly = append(summary.Hourly, HourlyClicks{Hour: start.UTC(), Clicks: hour.Clicks})
+	}
+
+	var err error
+	if summary.TopReferrers, err = topValues(inRange(), "referrer_host", top); err != nil {
+		return nil, err
+	}
+	if summary.TopUserAgents, err = topValues(inRange(), "user_agent_family", top); err != nil {
+		return nil, err
+	}
+	if summary.TopCountries, err = topValues(inRange().Where("country <> ''"), "country", top); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// hourExpression truncates clicked_at to the hour, formatted as time.DateTime.
+func (r *clickRepository) hourExpression() string {
+	if r.db.Dialector.Name() == "mysql" {
+		return "DATE_FORMAT(clicked_at, '%Y-%m-%d %H:00:00')"
+	}
+	return "strftime('%Y-%m-%d %H:00:00', clicked_at)"
+}
+
+// location is the time zone clicked_at is stored in: MySQL connections use loc=Local, while
+// SQLite keeps the UTC times clicks are recorded with.
+func (r *clickRepository) location() *time.Location {
+	if r.db.Dialector.Name() == "mysql" {
+		return time.Local
+	}
+	return time.UTC
+}
+
+func topValues(query *gorm.DB, column string, top int) ([]ValueCount, error) {
+	var entries []ValueCount
+	err := query.Select(column + " AS value, COUNT(*) AS count").
+		Group(column).
+		Order("count DESC, value").
+		Limit(top).
+		Scan(&entries).Error
+	return entries, err
+}
+
+func (r *clickRepository) CountByURLID(urlID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Click{}).Where("url_id = ?", urlID).Count(&count).Error
+	return count, err
+}
+
+func (r *clickRepository) DeleteByURLIDs(urlIDs []uint) (int64, error) {
+	if len(urlIDs) == 0 {
+		return 0, nil
+	}
+	result := r.db.Where("url_id IN ?", urlIDs).Delete(&models.Click{})
+	return result.RowsAffected, result.Error
+}
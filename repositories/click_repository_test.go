@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+	"url-shortener/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClickRepositorySummary(t *testing.T) {
+	factories := map[string]func(t *testing.T) ClickRepository{
+		"memory": func(t *testing.T) ClickRepository { return NewMemoryClickRepository() },
+		"sqlite": func(t *testing.T) ClickRepository {
+			db := newSQLiteTestDB(t)
+			require.NoError(t, db.AutoMigrate(&models.Click{}))
+			return NewClickRepository(db)
+		},
+	}
+	for name, newRepo := range factories {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+			click := func(urlID uint, at time.Duration, ip, host, family, country string) models.Click {
+				return models.Click{URLID: urlID, ClickedAt: day.Add(at), IPHash: ip, ReferrerHost: host, UserAgentFamily: family, Country: country}
+			}
+			require.NoError(t, repo.CreateBatch([]models.Click{
+				click(1, 90*time.Minute, "a", "twitter.com", "Chrome", "US"),
+				click(1, 95*time.Minute, "a", "direct", "Edge", ""),
+				click(1, 26*time.Hour, "b", "twitter.com", "curl", "DE"),
+				click(1, 49*time.Hour, "c", "example.com", "Chrome", "US"), // After the range
+				click(2, time.Hour, "d", "other.com", "Chrome", "US"),      // Another link
+			}))
+
+			summary, err := repo.SummarizeByURLID(1, day, day.Add(48*time.Hour), 2)
+			require.NoError(t, err)
+			assert.Equal(t, 3, summary.Clicks)
+			assert.Equal(t, 2, summary.UniqueVisitors)
+			assert.Equal(t, []HourlyClicks{{day.Add(time.Hour), 2}, {day.Add(26 * time.Hour), 1}}, summary.Hourly)
+			assert.Equal(t, []ValueCount{{"twitter.com", 2}, {"direct", 1}}, summary.TopReferrers)
+			assert.Equal(t, []ValueCount{{"Chrome", 1}, {"Edge", 1}}, summary.TopUserAgents, "ties are alphabetical and capped")
+			assert.Equal(t, []ValueCount{{"DE", 1}, {"US", 1}}, summary.TopCountries)
+
+			empty, err := repo.SummarizeByURLID(3, day, day.Add(48*time.Hour), 2)
+			require.NoError(t, err)
+			assert.Zero(t, empty.Clicks)
+			assert.Empty(t, empty.Hourly)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"time"
+	"url-shortener/models"
+
+	"gorm.io/gorm"
+)
+
+// LockRepository manages named leases. A lease is granted to one owner at a time and
+// lapses after its TTL, so a crashed holder can't block others forever.
+type LockRepository interface {
+	// TryAcquire takes or renews the lease, returning false if another owner holds it.
+	TryAcquire(name, owner string, ttl time.Duration) (bool, error)
+	Release(name, owner string) error
+}
+
+type lockRepository struct {
+	db *gorm.DB
+}
+
+func NewLockRepository(db *gorm.DB) LockRepository {
+	return &lockRepository{db: db}
+}
+
+func (r *lockRepository) TryAcquire(name, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+
+	// Renew our own lease or take over an expired one. A single conditional UPDATE is atomic.
+	result := r.db.Model(&models.Lock{}).
+		Where("name = ? AND (owner = ? OR expires_at < ?)", name, owner, now).
+		Updates(map[string]interface{}{"owner": owner, "expires_at": now.Add(ttl)})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	// No usable row: try to create it. The primary key makes concurrent inserts race safely.
+	if err := r.db.Create(&models.Lock{Name: name, Owner: owner, ExpiresAt: now.Add(ttl)}).Error; err != nil {
+		var existing models.Lock
+		if lookupErr := r.db.Where("name = ?", name).First(&existing).Error; lookupErr == nil {
+			return false, nil // Someone else got there first
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *lockRepository) Release(name, owner string) error {
+	return r.db.Where("name = ? AND owner = ?", name, owner).Delete(&models.Lock{}).Error
+}
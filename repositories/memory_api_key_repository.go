@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"errors"
+	"sync"
+	"time"
+	"url-shortener/models"
+)
+
+// ErrDuplicateKey mirrors the unique index on key_hash for the in-memory store.
+var ErrDuplicateKey = errors.New("api key already exists")
+
+// memoryAPIKeyRepository keeps API keys in a slice for the memory storage driver.
+type memoryAPIKeyRepository struct {
+	mu   sync.RWMutex
+	keys []models.APIKey
+}
+
+func NewMemoryAPIKeyRepository() APIKeyRepository {
+	return &memoryAPIKeyRepository{}
+}
+
+func (r *memoryAPIKeyRepository) Create(key *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.keys {
+		if existing.KeyHash == key.KeyHash {
+			return ErrDuplicateKey
+		}
+	}
+	key.ID = uint(len(r.keys) + 1)
+	key.CreatedAt = time.Now()
+	r.keys = append(r.keys, *key)
+	return nil
+}
+
+func (r *memoryAPIKeyRepository) FindByHash(keyHash string) (*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.KeyHash == keyHash {
+			found := key
+			return &found, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *memoryAPIKeyRepository) FindAll() ([]models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]models.APIKey(nil), r.keys...), nil
+}
+
+func (r *memoryAPIKeyRepository) Revoke(id uint, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.keys {
+		if r.keys[i].ID == id && r.keys[i].RevokedAt == nil {
+			r.keys[i].RevokedAt = &at
+			return nil
+		}
+	}
+	return ErrNotFound
+}
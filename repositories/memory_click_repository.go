@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"sort"
+	"sync"
+	"time"
+	"url-shortener/models"
+)
+
+// memoryClickRepository keeps clicks in a slice for the memory storage driver.
+type memoryClickRepository struct {
+	mu     sync.RWMutex
+	clicks []models.Click
+	nextID uint
+}
+
+func NewMemoryClickRepository() ClickRepository {
+	return &memoryClickRepository{}
+}
+
+func (r *memoryClickRepository) Create(click *models.Click) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	click.ID = r.nextID
+	r.clicks = append(r.clicks, *click)
+	return nil
+}
+
+func (r *memoryClickRepository) CreateBatch(clicks []models.Click) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range clicks {
+		r.nextID++
+		clicks[i].ID = r.nextID
+		r.clicks = append(r.clicks, clicks[i])
+	}
+	return nil
+}
+
+func (r *memoryClickRepository) SummarizeByURLID(urlID uint, from, to time.Time, top int) (*ClickSummary, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	summary := &ClickSummary{}
+	visitors := make(map[string]bool)
+	hours := make(map[time.Time]int)
+	referrers := make(map[string]int)
+	userAgents := make(map[string]int)
+	countries := make(map[string]int)
+	for _, click := range r.clicks {
+		if click.URLID != urlID || click.ClickedAt.Before(from) || !click.ClickedAt.Before(to) {
+			continue
+		}
+		summary.Clicks++
+		visitors[click.IPHash] = true
+		hours[click.ClickedAt.UTC().Truncate(time.Hour)]++
+		referrers[click.ReferrerHost]++
+		userAgents[click.UserAgentFamily]++
+		if click.Country != "" {
+			countries[click.Country]++
+		}
+	}
+
+	summary.UniqueVisitors = len(visitors)
+	for hour, clicks := range hours {
+		summary.Hourly = append(summary.Hourly, HourlyClicks{Hour: hour, Clicks: clicks})
+	}
+	sort.Slice(summary.Hourly, func(i, j int) bool { return summary.Hourly[i].Hour.Before(summary.Hourly[j].Hour) })
+	summary.TopReferrers = topCounts(referrers, top)
+	summary.TopUserAgents = topCounts(userAgents, top)
+	summary.TopCountries = topCounts(countries, top)
+	return summary, nil
+}
+
+func topCounts(counts map[string]int, top int) []ValueCount {
+	entries := make([]ValueCount, 0, len(counts))
+	for value, count := range counts {
+		entries = append(entries, ValueCount{Value: value, Count: count})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		return entries[i].Value < entries[j].Value
+	})
+	if len(entries) > top {
+		entries = entries[:top]
+	}
+	return entries
+}
+
+func (r *memoryClickRepository) CountByURLID(urlID uint) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, click := range r.clicks {
+		if click.URLID == urlID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryClickRepository) DeleteByURLIDs(urlIDs []uint) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := make(map[uint]bool, len(urlIDs))
+	for _, id := range urlIDs {
+		purged[id] = true
+	}
+	kept := r.clicks[:0]
+	for _, click := range r.clicks {
+		if !purged[click.URLID] {
+			kept = append(kept, click)
+		}
+	}
+	deleted := int64(len(r.clicks) - len(kept))
+	r.clicks = kept
+	return deleted, nil
+}
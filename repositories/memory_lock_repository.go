@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"sync"
+	"time"
+	"url-shortener/models"
+)
+
+// memoryLockRepository provides LockRepository semantics within a single process,
+// for the memory storage driver.
+type memoryLockRepository struct {
+	mu    sync.Mutex
+	locks map[string]models.Lock
+}
+
+func NewMemoryLockRepository() LockRepository {
+	return &memoryLockRepository{locks: make(map[string]models.Lock)}
+}
+
+func (r *memoryLockRepository) TryAcquire(name, owner string, ttl time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if held, exists := r.locks[name]; exists && held.Owner != owner && held.ExpiresAt.After(now) {
+		return false, nil
+	}
+	r.locks[name] = models.Lock{Name: name, Owner: owner, ExpiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+func (r *memoryLockRepository) Release(name, owner string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if held, exists := r.locks[name]; exists && held.Owner == owner {
+		delete(r.locks, name)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"sync"
+	"time"
+	"url-shortener/models"
+)
+
+// memoryReservedSlugRepository keeps reserved slugs in a slice for the memory storage driver.
+type memoryReservedSlugRepository struct {
+	mu      sync.RWMutex
+	entries []models.ReservedSlug
+	nextID  uint
+}
+
+func NewMemoryReservedSlugRepository() ReservedSlugRepository {
+	return &memoryReservedSlugRepository{}
+}
+
+func (r *memoryReservedSlugRepository) Create(entry *models.ReservedSlug) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.entries {
+		if existing.Value == entry.Value {
+			return ErrDuplicateReservedSlug
+		}
+	}
+	r.nextID++
+	entry.ID = r.nextID
+	entry.CreatedAt = time.Now()
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *memoryReservedSlugRepository) FindAll() ([]models.ReservedSlug, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make([]models.ReservedSlug, len(r.entries))
+	copy(entries, r.entries)
+	return entries, nil
+}
+
+func (r *memoryReservedSlugRepository) Delete(id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, entry := range r.entries {
+		if entry.ID == id {
+			r.entries = append(r.entries[:i], r.entries[i+1:]...)
+			return nil
+		}
+	}
+	return ErrNotFound
+}
@@ -0,0 +1,23 @@
+package repositories
+
+import "sync"
+
+// memorySequenceRepository provides SequenceRepository semantics within a single process,
+// for the memory storage driver.
+type memorySequenceRepository struct {
+	mu   sync.Mutex
+	next map[string]int64
+}
+
+func NewMemorySequenceRepository() SequenceRepository {
+	return &memorySequenceRepository{next: make(map[string]int64)}
+}
+
+func (r *memorySequenceRepository) Reserve(name string, size int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start := r.next[name]
+	r.next[name] = start + size
+	return start, nil
+}
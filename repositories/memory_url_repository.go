@@ -0,0 +1,248 @@
+package repositories
+
+import (
+	"sort"
+	"sync"
+	"time"
+	"url-shortener/models"
+
+	"gorm.io/gorm"
+)
+
+// memoryURLRepository keeps URLs in a map. It is intended for local development and tests,
+// so nothing survives a restart. Deleted links stay in the map, marked like gorm's soft
+// deletes, until they are purged.
+type memoryURLRepository struct {
+	mu     sync.RWMutex
+	urls   map[string]*models.URL
+	nextID uint
+}
+
+func NewMemoryURLRepository() URLRepository {
+	return &memoryURLRepository{urls: make(map[string]*models.URL)}
+}
+
+func (r *memoryURLRepository) Create(url *models.URL) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.urls[url.ShortLink]; exists {
+		return ErrDuplicateShortLink
+	}
+
+	r.nextID++
+	url.ID = r.nextID
+	stampCreated(url, time.Now())
+
+	stored := *url
+	r.urls[url.ShortLink] = &stored
+	return nil
+}
+
+func (r *memoryURLRepository) CreateBatch(urls []*models.URL) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Check every slug before inserting anything so a failed batch leaves no trace
+	seen := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		if _, exists := r.urls[url.ShortLink]; exists || seen[url.ShortLink] {
+			return ErrDuplicateShortLink
+		}
+		seen[url.ShortLink] = true
+	}
+
+	now := time.Now()
+	for _, url := range urls {
+		r.nextID++
+		url.ID = r.nextID
+		stampCreated(url, now)
+		stored := *url
+		r.urls[url.ShortLink] = &stored
+	}
+	return nil
+}
+
+// stampCreated sets unset timestamps the way gorm does on insert, so imported links keep theirs
+func stampCreated(url *models.URL, now time.Time) {
+	if url.CreatedAt.IsZero() {
+		url.CreatedAt = now
+	}
+	if url.UpdatedAt.IsZero() {
+		url.UpdatedAt = now
+	}
+}
+
+func (r *memoryURLRepository) FindByShortLink(shortLink string) (*models.URL, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, exists := r.urls[shortLink]
+	if !exists || stored.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+	url := *stored // Return a copy so callers can't mutate the store without Update
+	return &url, nil
+}
+
+func (r *memoryURLRepository) Delete(url *models.URL) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, exists := r.urls[url.ShortLink]; exists && !stored.DeletedAt.Valid {
+		stored.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		url.DeletedAt = stored.DeletedAt
+	}
+	return nil
+}
+
+func (r *memoryURLRepository) FindDeletedByShortLink(shortLink string) (*models.URL, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, exists := r.urls[shortLink]
+	if !exists || !stored.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+	url := *stored
+	return &url, nil
+}
+
+func (r *memoryURLRepository) Restore(url *models.URL) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, exists := r.urls[url.ShortLink]
+	if !exists || stored.ID != url.ID || !stored.DeletedAt.Valid {
+		return ErrNotFound
+	}
+	stored.DeletedAt = gorm.DeletedAt{}
+	url.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+func (r *memoryURLRepository) DeleteExpired(cutoff time.Time, limit int) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var expired []*models.URL
+	for _, stored := range r.urls {
+		if !stored.DeletedAt.Valid && stored.ExpirationDate.Before(cutoff) {
+			expired = append(expired, stored)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].ID < expired[j].ID })
+	if limit > 0 && len(expired) > limit {
+		expired = expired[:limit]
+	}
+	now := time.Now()
+	shortLinks := make([]string, len(expired))
+	for i, stored := range expired {
+		stored.DeletedAt = gorm.DeletedAt{Time: now, Valid: true}
+		shortLinks[i] = stored.ShortLink
+	}
+	return shortLinks, nil
+}
+
+func (r *memoryURLRepository) FindPurgeable(cutoff time.Time, limit int) ([]models.URL, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var urls []models.URL
+	for _, stored := range r.urls {
+		if stored.DeletedAt.Valid && stored.DeletedAt.Time.Before(cutoff) || stored.ExpirationDate.Before(cutoff) {
+			urls = append(urls, *stored)
+		}
+	}
+	sort.Slice(urls, func(i, j int) bool { return urls[i].ID < urls[j].ID })
+	if limit > 0 && len(urls) > limit {
+		urls = urls[:limit]
+	}
+	return urls, nil
+}
+
+func (r *memoryURLRepository) Purge(urls []models.URL) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := make(map[uint]bool, len(urls))
+	for _, url := range urls {
+		purged[url.ID] = true
+	}
+	for shortLink, stored := range r.urls {
+		if purged[stored.ID] {
+			delete(r.urls, shortLink)
+		}
+	}
+	return nil
+}
+
+func (r *memoryURLRepository) ExistsByShortLink(shortLink string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, exists := r.urls[shortLink]
+	return exists, nil
+}
+
+func (r *memoryURLRepository) Update(url *models.URL) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Short links may change on update, so locate the record by ID.
+	for shortLink, stored := range r.urls {
+		if stored.ID != url.ID || stored.DeletedAt.Valid {
+			continue
+		}
+		if shortLink != url.ShortLink {
+			if _, taken := r.urls[url.ShortLink]; taken {
+				return ErrDuplicateShortLink
+			}
+			delete(r.urls, shortLink)
+		}
+		url.UpdatedAt = time.Now()
+		updated := *url
+		r.urls[url.ShortLink] = &updated
+		return nil
+	}
+	return ErrNotFound
+}
+
+func (r *memoryURLRepository) List(query URLQuery) ([]models.URL, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sortBy := URLSortField(query.SortBy.column())
+	var urls []models.URL
+	for _, stored := range r.urls {
+		if stored.DeletedAt.Valid || !query.matches(stored) {
+			continue
+		}
+		if query.After != nil {
+			order := CursorFor(stored, sortBy).compare(*query.After)
+			if query.Descending {
+				order = -order
+			}
+			if order <= 0 {
+				continue
+			}
+		}
+		urls = append(urls, *stored)
+	}
+
+	sort.Slice(urls, func(i, j int) bool {
+		order := CursorFor(&urls[i], sortBy).compare(CursorFor(&urls[j], sortBy))
+		if query.Descending {
+			return order > 0
+		}
+		return order < 0
+	})
+	if query.Limit > 0 && len(urls) > query.Limit {
+		urls = urls[:query.Limit]
+	}
+	return urls, nil
+}
+
+func (r *memoryURLRepository) Ping() error {
+	return nil
+}
@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"errors"
+	"url-shortener/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrDuplicateReservedSlug is returned when a reserved word or pattern is stored already.
+var ErrDuplicateReservedSlug = errors.New("reserved slug already exists")
+
+type ReservedSlugRepository interface {
+	Create(entry *models.ReservedSlug) error
+	FindAll() ([]models.ReservedSlug, error)
+	Delete(id uint) error
+}
+
+type reservedSlugRepository struct {
+	db *gorm.DB
+}
+
+func NewReservedSlugRepository(db *gorm.DB) ReservedSlugRepository {
+	return &reservedSlugRepository{db: db}
+}
+
+func (r *reservedSlugRepository) Create(entry *models.ReservedSlug) error {
+	err := r.db.Create(entry).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicateReservedSlug
+	}
+	return err
+}
+
+func (r *reservedSlugRepository) FindAll() ([]models.ReservedSlug, error) {
+	var entries []models.ReservedSlug
+	err := r.db.Order("id").Find(&entries).Error
+	return entries, err
+}
+
+func (r *reservedSlugRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.ReservedSlug{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"url-shortener/models"
+
+	"gorm.io/gorm"
+)
+
+// SequenceRepository leases disjoint blocks of named counters. Each caller hands out the
+// values of its block locally, so values are unique across instances.
+type SequenceRepository interface {
+	// Reserve leases the next size values of the sequence and returns the first. A new
+	// sequence starts at 0.
+	Reserve(name string, size int64) (int64, error)
+}
+
+type sequenceRepository struct {
+	db *gorm.DB
+}
+
+func NewSequenceRepository(db *gorm.DB) SequenceRepository {
+	return &sequenceRepository{db: db}
+}
+
+func (r *sequenceRepository) Reserve(name string, size int64) (int64, error) {
+	start, err := r.reserve(name, size)
+	if err != nil {
+		// Two instances using a new sequence both try to insert it; the loser finds the
+		// row on the second attempt.
+		start, err = r.reserve(name, size)
+	}
+	return start, err
+}
+
+func (r *sequenceRepository) reserve(name string, size int64) (int64, error) {
+	var start int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// The increment locks the row until commit, so concurrent leases never overlap
+		result := tx.Model(&models.Sequence{}).Where("name = ?", name).
+			UpdateColumn("next_value", gorm.Expr("next_value + ?", size))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			start = 0
+			return tx.Create(&models.Sequence{Name: name, NextValue: size}).Error
+		}
+
+		var sequence models.Sequence
+		if err := tx.Where("name = ?", name).First(&sequence).Error; err != nil {
+			return err
+		}
+		start = sequence.NextValue - size
+		return nil
+	})
+	return start, err
+}
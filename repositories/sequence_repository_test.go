@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"testing"
+	"url-shortener/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequenceRepositoryReserve(t *testing.T) {
+	factories := map[string]func(t *testing.T) SequenceRepository{
+		"memory": func(t *testing.T) SequenceRepository { return NewMemorySequenceRepository() },
+		"sqlite": func(t *testing.T) SequenceRepository {
+			db := newSQLiteTestDB(t)
+			require.NoError(t, db.AutoMigrate(&models.Sequence{}))
+			return NewSequenceRepository(db)
+		},
+	}
+	for name, newRepo := range factories {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			for _, want := range []int64{0, 10, 20} {
+				start, err := repo.Reserve("slugs", 10)
+				require.NoError(t, err)
+				assert.Equal(t, want, start)
+			}
+
+			// Sequences are independent
+			start, err := repo.Reserve("other", 5)
+			require.NoError(t, err)
+			assert.Zero(t, start)
+			start, err = repo.Reserve("slugs", 5)
+			require.NoError(t, err)
+			assert.Equal(t, int64(30), start)
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package repositories
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+	"url-shortener/logging"
+	"url-shortener/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache keys: live links and tombstones are looked up separately, and both are cached.
+const (
+	liveCacheKey    = "live:"
+	deletedCacheKey = "deleted:"
+)
+
+// urlCacheTier stores link lookups. A nil URL is a negative entry: the lookup found nothing.
+type urlCacheTier interface {
+	Get(key string) (url *models.URL, found bool, err error)
+	Set(key string, url *models.URL, ttl time.Duration) error
+	Delete(keys ...string) error
+}
+
+// URLCacheConfig configures NewCachedURLRepository. Zero values fall back to defaults.
+type URLCacheConfig struct {
+	Size int // Links kept in process, default 10000
+	// LocalTTL is how long links stay in process. Other replicas' writes only invalidate the
+	// Redis tier, so this bounds how stale a replica can be. Default 30s.
+	LocalTTL    time.Duration
+	RedisTTL    time.Duration // How long links stay in Redis, default 10m
+	NegativeTTL time.Duration // How long unknown slugs are remembered in either tier, default 10s
+}
+
+// URLCacheStats are cumulative counters since the cache was created.
+type URLCacheStats struct {
+	Hits          int64 `json:"hits"`
+	RedisHits     int64 `json:"redisHits"`    // Hits served by Redis rather than in process
+	NegativeHits  int64 `json:"negativeHits"` // Hits for slugs cached as unknown
+	Misses        int64 `json:"misses"`
+	Invalidations int64 `json:"invalidations"`
+	Errors        int64 `json:"errors"` // Failed Redis calls; lookups fall through to the database
+	Entries       int   `json:"entries"`
+}
+
+// CachedURLRepository is a read-through cache in front of a URLRepository's short link
+// lookups, in process and optionally in Redis shared by all replicas. Slugs that don't
+// exist are cached too, briefly, so scans for random slugs don't reach the database. Writes
+// through the repository invalidate the links they touch.
+type CachedURLRepository struct {
+	URLRepository
+	local  *localURLCache
+	redis  urlCacheTier // nil without Redis
+	config URLCacheConfig
+
+	// generation changes with every invalidation, so a lookup that raced with a write
+	// doesn't cache what it read before the write
+	generation atomic.Uint64
+
+	hits          atomic.Int64
+	redisHits     atomic.Int64
+	negativeHits  atomic.Int64
+	misses        atomic.Int64
+	invalidations atomic.Int64
+	errors        atomic.Int64
+}
+
+// NewCachedURLRepository caches inner's lookups. client adds the Redis tier; it may be nil.
+func NewCachedURLRepository(inner URLRepository, client *redis.Client, config URLCacheConfig) *CachedURLRepository {
+	if config.Size <= 0 {
+		config.Size = 10000
+	}
+	if config.LocalTTL <= 0 {
+		config.LocalTTL = 30 * time.Second
+	}
+	if config.RedisTTL <= 0 {
+		config.RedisTTL = 10 * time.Minute
+	}
+	if config.NegativeTTL <= 0 {
+		config.NegativeTTL = 10 * time.Second
+	}
+	cache := &CachedURLRepository{URLRepository: inner, local: newLocalURLCache(config.Size), config: config}
+	if client != nil {
+		cache.redis = newRedisURLCache(client)
+	}
+	return cache
+}
+
+func (r *CachedURLRepository) FindByShortLink(shortLink string) (*models.URL, error) {
+	return r.lookup(liveCacheKey+shortLink, func() (*models.URL, error) {
+		return r.URLRepository.FindByShortLink(shortLink)
+	})
+}
+
+func (r *CachedURLRepository) FindDeletedByShortLink(shortLink string) (*models.URL, error) {
+	return r.lookup(deletedCacheKey+shortLink, func() (*models.URL, error) {
+		return r.URLRepository.FindDeletedByShortLink(shortLink)
+	})
+}
+
+func (r *CachedURLRepository) Create(url *models.URL) error {
+	err := r.URLRepository.Create(url)
+	if err == nil {
+		r.invalidate(url.ShortLink) // Forget that the slug was unknown
+	}
+	return err
+}
+
+func (r *CachedURLRepository) CreateBatch(urls []*models.URL) error {
+	err := r.URLRepository.CreateBatch(urls)
+	if err == nil {
+		slugs := make([]string, len(urls))
+		for i, url := range urls {
+			slugs[i] = url.ShortLink
+		}
+		r.invalidate(slugs...)
+	}
+	return err
+}
+
+func (r *CachedURLRepository) Update(url *models.URL) error {
+	defer r.invalidate(url.ShortLink)
+	return r.URLRepository.Update(url)
+}
+
+func (r *CachedURLRepository) Delete(url *models.URL) error {
+	defer r.invalidate(url.ShortLink)
+	return r.URLRepository.Delete(url)
+}
+
+func (r *CachedURLRepository) Restore(url *models.URL) error {
+	defer r.invalidate(url.ShortLink)
+	return r.URLRepository.Restore(url)
+}
+
+func (r *CachedURLRepository) DeleteExpired(cutoff time.Time, limit int) ([]string, error) {
+	shortLinks, err := r.URLRepository.DeleteExpired(cutoff, limit)
+	if len(shortLinks) > 0 {
+		r.invalidate(shortLinks...)
+	}
+	return shortLinks, err
+}
+
+func (r *CachedURLRepository) Purge(urls []models.URL) error {
+	defer func() {
+		shortLinks := make([]string, len(urls))
+		for i, url := range urls {
+			shortLinks[i] = url.ShortLink
+		}
+		r.invalidate(shortLinks...)
+	}()
+	return r.URLRepository.Purge(urls)
+}
+
+func (r *CachedURLRepository) Stats() URLCacheStats {
+	return URLCacheStats{
+		Hits:          r.hits.Load(),
+		RedisHits:     r.redisHits.Load(),
+		NegativeHits:  r.negativeHits.Load(),
+		Misses:        r.misses.Load(),
+		Invalidations: r.invalidations.Load(),
+		Errors:        r.errors.Load(),
+		Entries:       r.local.Len(),
+	}
+}
+
+// lookup serves key from the first tier that has it, filling the tiers in front of it, and
+// falls back to find. find's ErrNotFound is cached as a negative entry.
+func (r *CachedURLRepository) lookup(key string, find func() (*models.URL, error)) (*models.URL, error) {
+	if url, found, _ := r.local.Get(key); found {
+		return r.hit(url, false)
+	}
+	generation := r.generation.Load()
+	if r.redis != nil {
+		url, found, err := r.redis.Get(key)
+		if err != nil {
+			r.redisFailed(err, "read")
+		} else if found {
+			r.store(generation, key, url, false)
+			return r.hit(url, true)
+		}
+	}
+
+	r.misses.Add(1)
+	url, err := find()
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	r.store(generation, key, url, r.redis != nil)
+	return url, err
+}
+
+func (r *CachedURLRepository) hit(url *models.URL, fromRedis bool) (*models.URL, error) {
+	r.hits.Add(1)
+	if fromRedis {
+		r.redisHits.Add(1)
+	}
+	if url == nil {
+		r.negativeHits.Add(1)
+		return nil, ErrNotFound
+	}
+	return url, nil
+}
+
+// store caches a lookup in process and, with toRedis, in Redis, unless the cache was
+// invalidated since the lookup began.
+func (r *CachedURLRepository) store(generation uint64, key string, url *models.URL, toRedis bool) {
+	if r.generation.Load() != generation {
+		return
+	}
+	localTTL, redisTTL := r.config.LocalTTL, r.config.RedisTTL
+	if url == nil {
+		localTTL, redisTTL = min(localTTL, r.config.NegativeTTL), min(redisTTL, r.config.NegativeTTL)
+	}
+	r.local.Set(key, url, localTTL)
+	if toRedis {
+		if err := r.redis.Set(key, url, redisTTL); err != nil {
+			r.redisFailed(err, "write")
+		}
+	}
+}
+
+// invalidate drops everything cached about slugs from both tiers.
+func (r *CachedURLRepository) invalidate(slugs ...string) {
+	r.generation.Add(1)
+	r.invalidations.Add(int64(len(slugs)))
+	keys := make([]string, 0, 2*len(slugs))
+	for _, slug := range slugs {
+		keys = append(keys, liveCacheKey+slug, deletedCacheKey+slug)
+	}
+	r.local.Delete(keys...)
+	if r.redis != nil {
+		if err := r.redis.Delete(keys...); err != nil {
+			// Other replicas may serve the old link until RedisTTL passes
+			r.redisFailed(err, "invalidate")
+		}
+	}
+}
+
+func (r *CachedURLRepository) redisFailed(err error, op string) {
+	r.errors.Add(1)
+	logging.Log.WithError(err).WithField("op", op).Warn("Link cache: Redis call failed")
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"container/list"
+	"sync"
+	"time"
+	"url-shortener/models"
+)
+
+// localURLCache is an in-process LRU with per-entry expiry. It stores copies, so callers may
+// modify the links they get without touching the cache.
+type localURLCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // Front is the most recently used
+	entries map[string]*list.Element
+}
+
+type localCacheEntry struct {
+	key       string
+	url       *models.URL // nil for a negative entry
+	expiresAt time.Time
+}
+
+func newLocalURLCache(size int) *localURLCache {
+	return &localURLCache{size: size, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (c *localURLCache) Get(key string) (*models.URL, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, exists := c.entries[key]
+	if !exists {
+		return nil, false, nil
+	}
+	entry := element.Value.(*localCacheEntry)
+	if !time.Now().Before(entry.expiresAt) {
+		c.remove(element)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(element)
+	return copyURL(entry.url), true, nil
+}
+
+func (c *localURLCache) Set(key string, url *models.URL, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &localCacheEntry{key: key, url: copyURL(url), expiresAt: time.Now().Add(ttl)}
+	if element, exists := c.entries[key]; exists {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return nil
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+func (c *localURLCache) Delete(keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if element, exists := c.entries[key]; exists {
+			c.remove(element)
+		}
+	}
+	return nil
+}
+
+// Len counts entries, including expired ones not evicted yet.
+func (c *localURLCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *localURLCache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*localCacheEntry).key)
+}
+
+func copyURL(url *models.URL) *models.URL {
+	if url == nil {
+		return nil
+	}
+	copied := *url
+	return &copied
+}
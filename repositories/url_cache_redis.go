@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+	"url-shortener/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisURLCachePrefix namespaces link cache keys in a Redis shared with other data.
+const redisURLCachePrefix = "url-shortener:link:"
+
+// redisURLCache stores links as JSON, and negative entries as JSON null.
+type redisURLCache struct {
+	client *redis.Client
+}
+
+func newRedisURLCache(client *redis.Client) *redisURLCache {
+	return &redisURLCache{client: client}
+}
+
+func (c *redisURLCache) Get(key string) (*models.URL, bool, error) {
+	data, err := c.client.Get(context.Background(), redisURLCachePrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var url *models.URL
+	if err := json.Unmarshal(data, &url); err != nil {
+		return nil, false, err
+	}
+	return url, true, nil
+}
+
+func (c *redisURLCache) Set(key string, url *models.URL, ttl time.Duration) error {
+	data, err := json.Marshal(url)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(context.Background(), redisURLCachePrefix+key, data, ttl).Err()
+}
+
+func (c *redisURLCache) Delete(keys ...string) error {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = redisURLCachePrefix + key
+	}
+	return c.client.Del(context.Background(), prefixed...).Err()
+}
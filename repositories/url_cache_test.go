@@ -0,0 +1,173 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+	"url-shortener/models"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRedis starts an in-process Redis server and returns a client for it.
+func newTestRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// countingURLRepository counts the short link lookups that reach it.
+type countingURLRepository struct {
+	URLRepository
+	lookups int
+}
+
+func (r *countingURLRepository) FindByShortLink(shortLink string) (*models.URL, error) {
+	r.lookups++
+	return r.URLRepository.FindByShortLink(shortLink)
+}
+
+func TestCachedURLRepository(t *testing.T) {
+	inner := &countingURLRepository{URLRepository: NewMemoryURLRepository()}
+	cache := NewCachedURLRepository(inner, nil, URLCacheConfig{})
+	url := &models.URL{OriginalURL: "https://example.com", ShortLink: "cached", ExpirationDate: time.Now().Add(time.Hour)}
+	require.NoError(t, cache.Create(url))
+
+	found, err := cache.FindByShortLink("cached")
+	require.NoError(t, err)
+	found.OriginalURL = "https://mutated.example" // Callers' changes must not leak into the cache
+	found, err = cache.FindByShortLink("cached")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", found.OriginalURL)
+	assert.Equal(t, 1, inner.lookups)
+
+	t.Run("unknown slugs are cached until created", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			_, err := cache.FindByShortLink("later")
+			assert.ErrorIs(t, err, ErrNotFound)
+		}
+		assert.Equal(t, 2, inner.lookups)
+
+		require.NoError(t, cache.Create(&models.URL{OriginalURL: "https://example.org", ShortLink: "later", ExpirationDate: time.Now().Add(time.Hour)}))
+		_, err := cache.FindByShortLink("later")
+		assert.NoError(t, err)
+		assert.Equal(t, 3, inner.lookups)
+	})
+
+	t.Run("writes invalidate", func(t *testing.T) {
+		found.OriginalURL = "https://example.net"
+		require.NoError(t, cache.Update(found))
+		found, err := cache.FindByShortLink("cached")
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.net", found.OriginalURL)
+
+		require.NoError(t, cache.Delete(found))
+		_, err = cache.FindByShortLink("cached")
+		assert.ErrorIs(t, err, ErrNotFound)
+		tombstone, err := cache.FindDeletedByShortLink("cached")
+		require.NoError(t, err)
+
+		require.NoError(t, cache.Restore(tombstone))
+		_, err = cache.FindByShortLink("cached")
+		assert.NoError(t, err)
+		_, err = cache.FindDeletedByShortLink("cached")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	stats := cache.Stats()
+	assert.Equal(t, int64(inner.lookups), stats.Misses-2) // Two tombstone lookups missed too
+	assert.Equal(t, int64(3), stats.Hits)
+	assert.Equal(t, int64(2), stats.NegativeHits)
+}
+
+func TestCachedURLRepositoryExpiry(t *testing.T) {
+	inner := &countingURLRepository{URLRepository: NewMemoryURLRepository()}
+	cache := NewCachedURLRepository(inner, nil, URLCacheConfig{Size: 2, LocalTTL: time.Hour, NegativeTTL: 20 * time.Millisecond})
+	for _, slug := range []string{"one", "two", "three"} {
+		require.NoError(t, cache.Create(&models.URL{OriginalURL: "https://example.com", ShortLink: slug, ExpirationDate: time.Now().Add(time.Hour)}))
+		_, err := cache.FindByShortLink(slug)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, cache.Stats().Entries)
+
+	_, err := cache.FindByShortLink("one") // Least recently used, so evicted
+	require.NoError(t, err)
+	assert.Equal(t, 4, inner.lookups)
+
+	_, err = cache.FindByShortLink("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	time.Sleep(30 * time.Millisecond)
+	_, err = cache.FindByShortLink("missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 6, inner.lookups)
+}
+
+func TestCachedURLRepositorySweepAndPurge(t *testing.T) {
+	cache := NewCachedURLRepository(NewMemoryURLRepository(), newTestRedis(t), URLCacheConfig{})
+	now := time.Now()
+	require.NoError(t, cache.Create(&models.URL{OriginalURL: "https://example.com", ShortLink: "stale", ExpirationDate: now.Add(-time.Hour)}))
+	_, err := cache.FindByShortLink("stale")
+	require.NoError(t, err)
+
+	// Swept links must stop resolving from either tier
+	deleted, err := cache.DeleteExpired(now, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stale"}, deleted)
+	_, err = cache.FindByShortLink("stale")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = cache.FindDeletedByShortLink("stale")
+	require.NoError(t, err)
+
+	// Purged tombstones must not answer 410 until they time out
+	purgeable, err := cache.FindPurgeable(now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.NoError(t, cache.Purge(purgeable))
+	_, err = cache.FindDeletedByShortLink("stale")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestCachedURLRepositoryRedis(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	// Two replicas sharing a database and a Redis
+	inner := &countingURLRepository{URLRepository: NewMemoryURLRepository()}
+	first := NewCachedURLRepository(inner, client, URLCacheConfig{})
+	second := NewCachedURLRepository(inner, client, URLCacheConfig{})
+
+	url := &models.URL{OriginalURL: "https://example.com", ShortLink: "shared", ExpirationDate: time.Now().Add(time.Hour)}
+	require.NoError(t, first.Create(url))
+	_, err := first.FindByShortLink("shared")
+	require.NoError(t, err)
+	found, err := second.FindByShortLink("shared")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", found.OriginalURL)
+	assert.Equal(t, 1, inner.lookups)
+	assert.Equal(t, int64(1), second.Stats().RedisHits)
+	assert.Equal(t, 10*time.Minute, server.TTL(redisURLCachePrefix+liveCacheKey+"shared"))
+
+	_, err = second.FindByShortLink("nope")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 10*time.Second, server.TTL(redisURLCachePrefix+liveCacheKey+"nope"))
+	_, err = first.FindByShortLink("nope")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 2, inner.lookups)
+
+	// A write clears Redis; the other replica's own copy lives until LocalTTL
+	require.NoError(t, second.Delete(found))
+	assert.False(t, server.Exists(redisURLCachePrefix+liveCacheKey+"shared"))
+
+	t.Run("falls back to the database when Redis is down", func(t *testing.T) {
+		server.Close()
+		cache := NewCachedURLRepository(inner, client, URLCacheConfig{})
+		require.NoError(t, cache.Create(&models.URL{OriginalURL: "https://example.org", ShortLink: "outage", ExpirationDate: time.Now().Add(time.Hour)}))
+		found, err := cache.FindByShortLink("outage")
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.org", found.OriginalURL)
+		assert.Positive(t, cache.Stats().Errors)
+	})
+}
@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"cmp"
+	"strings"
+	"time"
+	"url-shortener/models"
+)
+
+// URLSortField is a column links can be listed by. Ties are broken by ID.
+type URLSortField string
+
+const (
+	SortByCreatedAt      URLSortField = "created_at"
+	SortByExpirationDate URLSortField = "expiration_date"
+	SortByShortLink      URLSortField = "short_link"
+)
+
+// column returns the database column for the sort field, defaulting to created_at so
+// that only known column names ever reach SQL.
+func (f URLSortField) column() string {
+	switch f {
+	case SortByExpirationDate, SortByShortLink:
+		return string(f)
+	default:
+		return string(SortByCreatedAt)
+	}
+}
+
+// URLCursor is the position of the last link on the previous page: its value for the
+// sort field and its ID.
+type URLCursor struct {
+	Time      time.Time // For SortByCreatedAt and SortByExpirationDate
+	ShortLink string    // For SortByShortLink
+	ID        uint
+}
+
+// URLQuery filters, orders and pages links for List. Zero values mean "no filter".
+type URLQuery struct {
+	OwnerID       *uint
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Expired       *bool // Relative to Now
+	Now           time.Time
+	Domain        string // Matches the destination host and its subdomains
+	SlugPrefix    string
+	Search        string // Substring of the destination URL
+
+	SortBy     URLSortField
+	Descending bool
+	After      *URLCursor
+	Limit      int
+}
+
+// CursorFor returns the cursor positioned at url for the given sort field.
+func CursorFor(url *models.URL, sortBy URLSortField) URLCursor {
+	cursor := URLCursor{ID: url.ID}
+	switch sortBy {
+	case SortByExpirationDate:
+		cursor.Time = url.ExpirationDate
+	case SortByShortLink:
+		cursor.ShortLink = url.ShortLink
+	default:
+		cursor.Time = url.CreatedAt
+	}
+	return cursor
+}
+
+// matches reports whether url passes every filter in the query; used by the memory store.
+func (q URLQuery) matches(url *models.URL) bool {
+	switch {
+	case q.OwnerID != nil && (url.OwnerID == nil || *url.OwnerID != *q.OwnerID):
+		return false
+	case !q.CreatedAfter.IsZero() && url.CreatedAt.Before(q.CreatedAfter):
+		return false
+	case !q.CreatedBefore.IsZero() && !url.CreatedAt.Before(q.CreatedBefore):
+		return false
+	case q.Expired != nil && *q.Expired != !url.ExpirationDate.After(q.Now):
+		return false
+	case q.SlugPrefix != "" && !strings.HasPrefix(strings.ToLower(url.ShortLink), strings.ToLower(q.SlugPrefix)):
+		return false
+	case q.Search != "" && !strings.Contains(strings.ToLower(url.OriginalURL), strings.ToLower(q.Search)):
+		return false
+	}
+	if q.Domain != "" {
+		domain := strings.ToLower(q.Domain)
+		if url.DestinationHost != domain && !strings.HasSuffix(url.DestinationHost, "."+domain) {
+			return false
+		}
+	}
+	return true
+}
+
+// compare orders a before b (negative), after b (positive) or equal, on the sort field then ID.
+func (c URLCursor) compare(other URLCursor) int {
+	if result := c.Time.Compare(other.Time); result != 0 {
+		return result
+	}
+	if result := strings.Compare(c.ShortLink, other.ShortLink); result != 0 {
+		return result
+	}
+	return cmp.Compare(c.ID, other.ID)
+}
+
+func (c URLCursor) value(sortBy URLSortField) interface{} {
+	if sortBy == SortByShortLink {
+		return c.ShortLink
+	}
+	return c.Time
+}
+
+// likeEscape escapes LIKE wildcards using '!' so the same pattern works on MySQL and
+// SQLite, which disagree about backslash escapes.
+func likeEscape(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
@@ -1,17 +1,44 @@
 package repositories
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 	"url-shortener/models"
 
 	"gorm.io/gorm"
 )
 
+// ErrNotFound is returned when no URL matches the requested short link.
+var ErrNotFound = errors.New("record not found")
+
+// ErrDuplicateShortLink is returned when a short link is stored already.
+var ErrDuplicateShortLink = errors.New("short link already exists")
+
 type URLRepository interface {
 	Create(url *models.URL) error
+	// CreateBatch stores all urls or, if any of them fails, none of them.
+	CreateBatch(urls []*models.URL) error
 	FindByShortLink(shortLink string) (*models.URL, error)
+	// Delete soft-deletes url. The row keeps its short link until it is purged.
 	Delete(url *models.URL) error
-	ExistsByShortLink(shortLink string) bool
+	// FindDeletedByShortLink returns a soft-deleted link, or ErrNotFound.
+	FindDeletedByShortLink(shortLink string) (*models.URL, error)
+	Restore(url *models.URL) error
+	// DeleteExpired soft-deletes up to limit live links, by ID, that expired before cutoff,
+	// returning the short links it deleted.
+	DeleteExpired(cutoff time.Time, limit int) ([]string, error)
+	// FindPurgeable returns up to limit links, by ID, that were deleted or expired before cutoff.
+	FindPurgeable(cutoff time.Time, limit int) ([]models.URL, error)
+	// Purge hard-deletes links found by FindPurgeable, freeing their short links.
+	Purge(urls []models.URL) error
+	// ExistsByShortLink counts deleted links too, since they hold their short link until purged.
+	ExistsByShortLink(shortLink string) (bool, error)
 	Update(url *models.URL) error
+	// List returns up to query.Limit links matching query, in its sort order.
+	List(query URLQuery) ([]models.URL, error)
+	Ping() error
 }
 
 type urlRepository struct {
@@ -23,12 +50,30 @@ func NewURLRepository(db *gorm.DB) URLRepository {
 }
 
 func (r *urlRepository) Create(url *models.URL) error {
-	return r.db.Create(url).Error
+	return duplicateError(r.db.Create(url).Error)
+}
+
+func (r *urlRepository) CreateBatch(urls []*models.URL) error {
+	return duplicateError(r.db.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(urls, 100).Error
+	}))
+}
+
+// duplicateError reports a unique violation as ErrDuplicateShortLink, the only unique
+// column a URL write can clash on. It needs a connection opened with TranslateError.
+func duplicateError(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return ErrDuplicateShortLink
+	}
+	return err
 }
 
 func (r *urlRepository) FindByShortLink(shortLink string) (*models.URL, error) {
 	var url models.URL
 	if err := r.db.Where("short_link = ?", shortLink).First(&url).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
 		return nil, err
 	}
 	return &url, nil
@@ -38,12 +83,128 @@ func (r *urlRepository) Delete(url *models.URL) error {
 	return r.db.Delete(url).Error
 }
 
-func (r *urlRepository) ExistsByShortLink(shortLink string) bool {
+func (r *urlRepository) FindDeletedByShortLink(shortLink string) (*models.URL, error) {
+	var url models.URL
+	if err := r.db.Unscoped().Where("short_link = ? AND deleted_at IS NOT NULL", shortLink).First(&url).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &url, nil
+}
+
+func (r *urlRepository) Restore(url *models.URL) error {
+	result := r.db.Unscoped().Model(&models.URL{}).Where("id = ? AND deleted_at IS NOT NULL", url.ID).Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	url.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+func (r *urlRepository) DeleteExpired(cutoff time.Time, limit int) ([]string, error) {
+	var expired []models.URL
+	if err := r.db.Select("id", "short_link").Where("expiration_date < ?", cutoff).Order("id").Limit(limit).Find(&expired).Error; err != nil {
+		return nil, err
+	}
+	if len(expired) == 0 {
+		return nil, nil
+	}
+	ids := make([]uint, len(expired))
+	shortLinks := make([]string, len(expired))
+	for i, url := range expired {
+		ids[i], shortLinks[i] = url.ID, url.ShortLink
+	}
+	if err := r.db.Where("id IN ?", ids).Delete(&models.URL{}).Error; err != nil {
+		return nil, err
+	}
+	return shortLinks, nil
+}
+
+func (r *urlRepository) FindPurgeable(cutoff time.Time, limit int) ([]models.URL, error) {
+	var urls []models.URL
+	err := r.db.Unscoped().
+		Where("deleted_at < ? OR expiration_date < ?", cutoff, cutoff).
+		Order("id").Limit(limit).Find(&urls).Error
+	return urls, err
+}
+
+func (r *urlRepository) Purge(urls []models.URL) error {
+	if len(urls) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(urls))
+	for i, url := range urls {
+		ids[i] = url.ID
+	}
+	return r.db.Unscoped().Where("id IN ?", ids).Delete(&models.URL{}).Error
+}
+
+func (r *urlRepository) ExistsByShortLink(shortLink string) (bool, error) {
 	var count int64
-	r.db.Model(&models.URL{}).Where("short_link = ?", shortLink).Count(&count)
-	return count > 0
+	if err := r.db.Unscoped().Model(&models.URL{}).Where("short_link = ?", shortLink).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
 }
 
 func (r *urlRepository) Update(url *models.URL) error {
 	return r.db.Save(url).Error
 }
+
+func (r *urlRepository) List(query URLQuery) ([]models.URL, error) {
+	db := r.db.Model(&models.URL{})
+	if query.OwnerID != nil {
+		db = db.Where("owner_id = ?", *query.OwnerID)
+	}
+	if !query.CreatedAfter.IsZero() {
+		db = db.Where("created_at >= ?", query.CreatedAfter)
+	}
+	if !query.CreatedBefore.IsZero() {
+		db = db.Where("created_at < ?", query.CreatedBefore)
+	}
+	if query.Expired != nil {
+		if *query.Expired {
+			db = db.Where("expiration_date <= ?", query.Now)
+		} else {
+			db = db.Where("expiration_date > ?", query.Now)
+		}
+	}
+	if query.Domain != "" {
+		domain := strings.ToLower(query.Domain)
+		db = db.Where("(destination_host = ? OR destination_host LIKE ? ESCAPE '!')", domain, "%."+likeEscape(domain))
+	}
+	if query.SlugPrefix != "" {
+		db = db.Where("short_link LIKE ? ESCAPE '!'", likeEscape(query.SlugPrefix)+"%")
+	}
+	if query.Search != "" {
+		db = db.Where("original_url LIKE ? ESCAPE '!'", "%"+likeEscape(query.Search)+"%")
+	}
+
+	// Keyset pagination: continue strictly after the cursor in (sort column, id) order
+	column := query.SortBy.column()
+	direction, comparison := "ASC", ">"
+	if query.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	if query.After != nil {
+		value := query.After.value(query.SortBy)
+		db = db.Where(fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", column, comparison, column, comparison), value, value, query.After.ID)
+	}
+
+	var urls []models.URL
+	err := db.Order(column + " " + direction).Order("id " + direction).Limit(query.Limit).Find(&urls).Error
+	return urls, err
+}
+
+func (r *urlRepository) Ping() error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Ping()
+}
@@ -0,0 +1,221 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+	"url-shortener/models"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// newSQLiteTestDB opens a private in-memory SQLite database with the schema applied.
+func newSQLiteTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.URL{}))
+	t.Cleanup(func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	})
+	return db
+}
+
+// repositoryFactories runs each contract test against every URLRepository implementation.
+func repositoryFactories() map[string]func(t *testing.T) URLRepository {
+	return map[string]func(t *testing.T) URLRepository{
+		"memory": func(t *testing.T) URLRepository { return NewMemoryURLRepository() },
+		"sqlite": func(t *testing.T) URLRepository { return NewURLRepository(newSQLiteTestDB(t)) },
+		"cached": func(t *testing.T) URLRepository {
+			return NewCachedURLRepository(NewURLRepository(newSQLiteTestDB(t)), newTestRedis(t), URLCacheConfig{})
+		},
+	}
+}
+
+func TestURLRepositoryContract(t *testing.T) {
+	for name, newRepo := range repositoryFactories() {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			assert.NoError(t, repo.Ping())
+
+			url := &models.URL{OriginalURL: "https://example.com", ShortLink: "abc123", ExpirationDate: time.Now().Add(time.Hour)}
+			require.NoError(t, repo.Create(url))
+			assert.NotZero(t, url.ID)
+
+			exists, err := repo.ExistsByShortLink("abc123")
+			require.NoError(t, err)
+			assert.True(t, exists)
+
+			assert.ErrorIs(t, repo.Create(&models.URL{OriginalURL: "https://other.com", ShortLink: "abc123", ExpirationDate: time.Now()}), ErrDuplicateShortLink)
+
+			found, err := repo.FindByShortLink("abc123")
+			require.NoError(t, err)
+			assert.Equal(t, "https://example.com", found.OriginalURL)
+
+			found.OriginalURL = "https://example.org"
+			require.NoError(t, repo.Update(found))
+			found, err = repo.FindByShortLink("abc123")
+			require.NoError(t, err)
+			assert.Equal(t, "https://example.org", found.OriginalURL)
+
+			require.NoError(t, repo.Delete(found))
+			_, err = repo.FindByShortLink("abc123")
+			assert.ErrorIs(t, err, ErrNotFound)
+
+			// The tombstone keeps the short link until it is purged
+			exists, err = repo.ExistsByShortLink("abc123")
+			require.NoError(t, err)
+			assert.True(t, exists)
+		})
+	}
+}
+
+func TestURLRepositoryTombstones(t *testing.T) {
+	for name, newRepo := range repositoryFactories() {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			now := time.Now()
+			deleted := &models.URL{OriginalURL: "https://example.com/deleted", ShortLink: "deleted", ExpirationDate: now.Add(time.Hour)}
+			expired := &models.URL{OriginalURL: "https://example.com/expired", ShortLink: "expired", ExpirationDate: now.Add(-time.Hour)}
+			live := &models.URL{OriginalURL: "https://example.com/live", ShortLink: "live", ExpirationDate: now.Add(time.Hour)}
+			require.NoError(t, repo.CreateBatch([]*models.URL{deleted, expired, live}))
+			require.NoError(t, repo.Delete(deleted))
+
+			_, err := repo.FindDeletedByShortLink("live")
+			assert.ErrorIs(t, err, ErrNotFound)
+			tombstone, err := repo.FindDeletedByShortLink("deleted")
+			require.NoError(t, err)
+			assert.True(t, tombstone.DeletedAt.Valid)
+			assert.ErrorIs(t, repo.Create(&models.URL{OriginalURL: "https://other.com", ShortLink: "deleted", ExpirationDate: now.Add(time.Hour)}), ErrDuplicateShortLink)
+
+			page, err := repo.List(URLQuery{Limit: 10})
+			require.NoError(t, err)
+			assert.Len(t, page, 2)
+
+			// Only what was deleted or expired before the cutoff is purgeable
+			purgeable, err := repo.FindPurgeable(now.Add(-2*time.Hour), 10)
+			require.NoError(t, err)
+			assert.Empty(t, purgeable)
+			purgeable, err = repo.FindPurgeable(now.Add(time.Minute), 10)
+			require.NoError(t, err)
+			require.Len(t, purgeable, 2)
+			assert.Equal(t, deleted.ID, purgeable[0].ID)
+			assert.Equal(t, expired.ID, purgeable[1].ID)
+			purgeable, err = repo.FindPurgeable(now.Add(time.Minute), 1)
+			require.NoError(t, err)
+			assert.Len(t, purgeable, 1)
+
+			require.NoError(t, repo.Restore(tombstone))
+			assert.ErrorIs(t, repo.Restore(tombstone), ErrNotFound)
+			_, err = repo.FindByShortLink("deleted")
+			require.NoError(t, err)
+
+			deletedSlugs, err := repo.DeleteExpired(now, 10)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"expired"}, deletedSlugs)
+			_, err = repo.FindDeletedByShortLink("expired")
+			require.NoError(t, err)
+			deletedSlugs, err = repo.DeleteExpired(now, 10)
+			require.NoError(t, err)
+			assert.Empty(t, deletedSlugs)
+
+			require.NoError(t, repo.Purge([]models.URL{*expired}))
+			exists, err := repo.ExistsByShortLink("expired")
+			require.NoError(t, err)
+			assert.False(t, exists)
+			require.NoError(t, repo.Create(&models.URL{OriginalURL: "https://other.com", ShortLink: "expired", ExpirationDate: now.Add(time.Hour)}))
+		})
+	}
+}
+
+func TestURLRepositoryCreateBatch(t *testing.T) {
+	for name, newRepo := range repositoryFactories() {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			expires := time.Now().Add(time.Hour)
+			newURL := func(slug string) *models.URL {
+				return &models.URL{OriginalURL: "https://example.com/" + slug, ShortLink: slug, ExpirationDate: expires}
+			}
+
+			batch := []*models.URL{newURL("one"), newURL("two")}
+			require.NoError(t, repo.CreateBatch(batch))
+			assert.NotZero(t, batch[0].ID)
+			assert.NotZero(t, batch[1].ID)
+
+			// One conflicting slug rolls back the whole batch
+			assert.ErrorIs(t, repo.CreateBatch([]*models.URL{newURL("three"), newURL("two")}), ErrDuplicateShortLink)
+			exists, err := repo.ExistsByShortLink("three")
+			require.NoError(t, err)
+			assert.False(t, exists)
+
+			assert.Error(t, repo.CreateBatch([]*models.URL{newURL("four"), newURL("four")}))
+			exists, err = repo.ExistsByShortLink("four")
+			require.NoError(t, err)
+			assert.False(t, exists)
+		})
+	}
+}
+
+func TestURLRepositoryList(t *testing.T) {
+	for name, newRepo := range repositoryFactories() {
+		t.Run(name, func(t *testing.T) {
+			repo := newRepo(t)
+			now := time.Now()
+			alice := uint(1)
+			fixtures := []models.URL{
+				{ShortLink: "blog1", OriginalURL: "https://blog.example.com/posts/1", DestinationHost: "blog.example.com", ExpirationDate: now.Add(time.Hour), OwnerID: &alice},
+				{ShortLink: "blog2", OriginalURL: "https://blog.example.com/posts/2", DestinationHost: "blog.example.com", ExpirationDate: now.Add(-time.Hour), OwnerID: &alice},
+				{ShortLink: "docs1", OriginalURL: "https://docs.other.org/100%_guide", DestinationHost: "docs.other.org", ExpirationDate: now.Add(2 * time.Hour)},
+				{ShortLink: "shop1", OriginalURL: "https://example.com/shop", DestinationHost: "example.com", ExpirationDate: now.Add(3 * time.Hour)},
+				{ShortLink: "shop2", OriginalURL: "https://notexample.com/shop", DestinationHost: "notexample.com", ExpirationDate: now.Add(4 * time.Hour)},
+			}
+			for i := range fixtures {
+				require.NoError(t, repo.Create(&fixtures[i]))
+			}
+
+			slugs := func(query URLQuery) []string {
+				if query.Limit == 0 {
+					query.Limit = 100
+				}
+				urls, err := repo.List(query)
+				require.NoError(t, err)
+				result := []string{}
+				for _, url := range urls {
+					result = append(result, url.ShortLink)
+				}
+				return result
+			}
+			expired, active := true, false
+
+			assert.Equal(t, []string{"blog1", "blog2", "docs1", "shop1", "shop2"}, slugs(URLQuery{SortBy: SortByShortLink}))
+			assert.Equal(t, []string{"shop2", "shop1", "docs1", "blog1", "blog2"}, slugs(URLQuery{SortBy: SortByExpirationDate, Descending: true}))
+			assert.Equal(t, []string{"blog1", "blog2"}, slugs(URLQuery{SortBy: SortByShortLink, OwnerID: &alice}))
+			assert.Equal(t, []string{"blog2"}, slugs(URLQuery{SortBy: SortByShortLink, Expired: &expired, Now: now}))
+			assert.Len(t, slugs(URLQuery{Expired: &active, Now: now}), 4)
+			assert.Equal(t, []string{"blog1", "blog2", "shop1"}, slugs(URLQuery{SortBy: SortByShortLink, Domain: "Example.com"}))
+			assert.Equal(t, []string{"shop1", "shop2"}, slugs(URLQuery{SortBy: SortByShortLink, SlugPrefix: "sh"}))
+			assert.Equal(t, []string{"docs1"}, slugs(URLQuery{SortBy: SortByShortLink, Search: "100%_"}))
+			assert.Empty(t, slugs(URLQuery{Search: "1000"}))
+
+			// Walk all pages two at a time
+			var walked []string
+			query := URLQuery{SortBy: SortByShortLink, Descending: true, Limit: 2}
+			for {
+				urls, err := repo.List(query)
+				require.NoError(t, err)
+				for _, url := range urls {
+					walked = append(walked, url.ShortLink)
+				}
+				if len(urls) < query.Limit {
+					break
+				}
+				cursor := CursorFor(&urls[len(urls)-1], query.SortBy)
+				query.After = &cursor
+			}
+			assert.Equal(t, []string{"shop2", "shop1", "docs1", "blog2", "blog1"}, walked)
+		})
+	}
+}
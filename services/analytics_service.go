@@ -0,0 +1,204 @@
+// services/analytics_service.go
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+	"url-shortener/models"
+	"url-shortener/repositories"
+	"url-shortener/utils"
+)
+
+const (
+	// DefaultStatsWindow is used when a stats request doesn't specify a range.
+	DefaultStatsWindow = 30 * 24 * time.Hour
+	// MaxStatsWindow bounds how many clicks a single stats request aggregates, and how many
+	// hourly buckets it returns.
+	MaxStatsWindow = 90 * 24 * time.Hour
+
+	topEntriesLimit       = 10
+	maxReferrerLength     = 2048
+	maxReferrerHostLength = 255
+	maxUALength           = 512
+)
+
+var ErrInvalidStatsRange = errors.New("invalid stats range: from must be before to and span at most 90 days")
+
+// Visit describes a redirect as seen by the HTTP layer.
+type Visit struct {
+	Time      time.Time
+	Referrer  string
+	UserAgent string
+	ClientIP  string
+	Country   string
+}
+
+// LinkStats aggregates the clicks of one short link over [From, To).
+type LinkStats struct {
+	ShortLink      string
+	TotalClicks    int64 // All time
+	From           time.Time
+	To             time.Time
+	Clicks         int // Within the range
+	UniqueVisitors int
+	Daily          []TimeBucket
+	Hourly         []TimeBucket
+	TopReferrers   []CountEntry
+	TopUserAgents  []CountEntry
+	TopCountries   []CountEntry
+}
+
+type TimeBucket struct {
+	Start  time.Time
+	Clicks int
+}
+
+type CountEntry struct {
+	Value string
+	Count int
+}
+
+type AnalyticsService interface {
+	RecordClick(url *models.URL, visit Visit) error
+	// GetStats aggregates a link's clicks. Like changing a link, it is reserved for the link's
+	// owner and admins.
+	GetStats(shortLink string, from, to time.Time, actor *models.APIKey) (*LinkStats, error)
+}
+
+type analyticsService struct {
+	urlRepo   repositories.URLRepository
+	clickRepo repositories.ClickRepository
+	recorder  *ClickRecorder
+	ipSalt    []byte
+}
+
+// NewAnalyticsService creates the analytics service. Clicks go through recorder when one is
+// given and are written synchronously otherwise. ipSalt keys the hash applied to client IPs
+// so that stored hashes can't be reversed by enumerating the address space.
+func NewAnalyticsService(urlRepo repositories.URLRepository, clickRepo repositories.ClickRepository, recorder *ClickRecorder, ipSalt string) AnalyticsService {
+	return &analyticsService{urlRepo: urlRepo, clickRepo: clickRepo, recorder: recorder, ipSalt: []byte(ipSalt)}
+}
+
+func (s *analyticsService) RecordClick(url *models.URL, visit Visit) error {
+	click := s.newClick(url, visit)
+	if s.recorder != nil {
+		return s.recorder.Enqueue(click)
+	}
+	return s.clickRepo.Create(click)
+}
+
+func (s *analyticsService) newClick(url *models.URL, visit Visit) *models.Click {
+	clickedAt := visit.Time
+	if clickedAt.IsZero() {
+		clickedAt = time.Now()
+	}
+	return &models.Click{
+		URLID:           url.ID,
+		ClickedAt:       clickedAt.UTC(),
+		Referrer:        truncate(visit.Referrer, maxReferrerLength),
+		UserAgent:       truncate(visit.UserAgent, maxUALength),
+		IPHash:          s.hashIP(visit.ClientIP),
+		Country:         strings.ToUpper(truncate(visit.Country, 8)),
+		ReferrerHost:    truncate(utils.ReferrerHost(visit.Referrer), maxReferrerHostLength),
+		UserAgentFamily: utils.UserAgentFamily(visit.UserAgent),
+	}
+}
+
+func (s *analyticsService) GetStats(shortLink string, from, to time.Time, actor *models.APIKey) (*LinkStats, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-DefaultStatsWindow)
+	}
+	if !from.Before(to) || to.Sub(from) > MaxStatsWindow {
+		return nil, ErrInvalidStatsRange
+	}
+	from, to = from.UTC(), to.UTC()
+
+	url, err := s.urlRepo.FindByShortLink(shortLink)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrURLNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !CanModify(actor, url) {
+		return nil, ErrForbidden
+	}
+
+	total, err := s.clickRepo.CountByURLID(url.ID)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := s.clickRepo.SummarizeByURLID(url.ID, from, to, topEntriesLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &LinkStats{
+		ShortLink:      url.ShortLink,
+		TotalClicks:    total,
+		From:           from,
+		To:             to,
+		Clicks:         summary.Clicks,
+		UniqueVisitors: summary.UniqueVisitors,
+		Daily:          newBuckets(from.Truncate(24*time.Hour), to, 24*time.Hour),
+		Hourly:         newBuckets(from.Truncate(time.Hour), to, time.Hour),
+		TopReferrers:   countEntries(summary.TopReferrers),
+		TopUserAgents:  countEntries(summary.TopUserAgents),
+		TopCountries:   countEntries(summary.TopCountries),
+	}
+	for _, hour := range summary.Hourly {
+		addToBucket(stats.Daily, hour.Hour, 24*time.Hour, hour.Clicks)
+		addToBucket(stats.Hourly, hour.Hour, time.Hour, hour.Clicks)
+	}
+	return stats, nil
+}
+
+func (s *analyticsService) hashIP(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, s.ipSalt)
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// newBuckets returns zero-filled buckets of the given size covering [start, end).
+func newBuckets(start, end time.Time, size time.Duration) []TimeBucket {
+	var buckets []TimeBucket
+	for t := start; t.Before(end); t = t.Add(size) {
+		buckets = append(buckets, TimeBucket{Start: t})
+	}
+	return buckets
+}
+
+func addToBucket(buckets []TimeBucket, at time.Time, size time.Duration, clicks int) {
+	if len(buckets) == 0 {
+		return
+	}
+	index := int(at.Sub(buckets[0].Start) / size)
+	if index >= 0 && index < len(buckets) {
+		buckets[index].Clicks += clicks
+	}
+}
+
+func countEntries(values []repositories.ValueCount) []CountEntry {
+	entries := make([]CountEntry, len(values))
+	for i, value := range values {
+		entries[i] = CountEntry{Value: value.Value, Count: value.Count}
+	}
+	return entries
+}
+
+func truncate(value string, max int) string {
+	if len(value) > max {
+		return strings.ToValidUTF8(value[:max], "")
+	}
+	return value
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"testing"
+	"time"
+	"url-shortener/models"
+	"url-shortener/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStatsAggregatesClicks(t *testing.T) {
+	urlRepo := repositories.NewMemoryURLRepository()
+	service := NewAnalyticsService(urlRepo, repositories.NewMemoryClickRepository(), nil, "salt")
+
+	owner := &models.APIKey{ID: 1}
+	url := &models.URL{OriginalURL: "https://example.com", ShortLink: "stats1", ExpirationDate: time.Now().Add(time.Hour), OwnerID: &owner.ID}
+	require.NoError(t, urlRepo.Create(url))
+
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	visits := []Visit{
+		{Time: day.Add(1 * time.Hour), ClientIP: "10.0.0.1", Referrer: "https://www.twitter.com/x", UserAgent: "Mozilla/5.0 Chrome/120.0 Safari/537.36", Country: "us"},
+		{Time: day.Add(1 * time.Hour), ClientIP: "10.0.0.1", Referrer: "https://twitter.com/y", UserAgent: "Mozilla/5.0 Chrome/120.0 Safari/537.36 Edg/120.0"},
+		{Time: day.Add(26 * time.Hour), ClientIP: "10.0.0.2", UserAgent: "curl/8.0"},
+	}
+	for _, visit := range visits {
+		require.NoError(t, service.RecordClick(url, visit))
+	}
+
+	_, err := service.GetStats("stats1", day, day.Add(48*time.Hour), &models.APIKey{ID: 2})
+	assert.ErrorIs(t, err, ErrForbidden, "stats are for the owner only")
+	stats, err := service.GetStats("stats1", day, day.Add(48*time.Hour), owner)
+	require.NoError(t, err)
+
+	assert.EqualValues(t, 3, stats.TotalClicks)
+	assert.Equal(t, 3, stats.Clicks)
+	assert.Equal(t, 2, stats.UniqueVisitors)
+	assert.Len(t, stats.Daily, 2)
+	assert.Equal(t, 2, stats.Daily[0].Clicks)
+	assert.Equal(t, 1, stats.Daily[1].Clicks)
+	assert.Len(t, stats.Hourly, 48)
+	assert.Equal(t, 2, stats.Hourly[1].Clicks)
+	assert.Equal(t, []CountEntry{{"twitter.com", 2}, {"direct", 1}}, stats.TopReferrers)
+	assert.Equal(t, []CountEntry{{"Chrome", 1}, {"Edge", 1}, {"curl", 1}}, stats.TopUserAgents)
+	assert.Equal(t, []CountEntry{{"US", 1}}, stats.TopCountries)
+}
+
+func TestGetStatsValidatesRange(t *testing.T) {
+	service := NewAnalyticsService(repositories.NewMemoryURLRepository(), repositories.NewMemoryClickRepository(), nil, "")
+	now := time.Now()
+
+	admin := &models.APIKey{IsAdmin: true}
+	_, err := service.GetStats("missing", now, now.Add(-time.Hour), admin)
+	assert.ErrorIs(t, err, ErrInvalidStatsRange)
+
+	_, err = service.GetStats("missing", now.Add(-MaxStatsWindow-time.Hour), now, admin)
+	assert.ErrorIs(t, err, ErrInvalidStatsRange)
+
+	_, err = service.GetStats("missing", time.Time{}, time.Time{}, admin)
+	assert.ErrorIs(t, err, ErrURLNotFound)
+}
+
+func TestHashIPIsSaltedAndStable(t *testing.T) {
+	a := &analyticsService{ipSalt: []byte("one")}
+	b := &analyticsService{ipSalt: []byte("two")}
+	assert.Equal(t, a.hashIP("192.0.2.1"), a.hashIP("192.0.2.1"))
+	assert.NotEqual(t, a.hashIP("192.0.2.1"), b.hashIP("192.0.2.1"))
+	assert.NotContains(t, a.hashIP("192.0.2.1"), "192")
+	assert.Empty(t, a.hashIP(""))
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"url-shortener/models"
+	"url-shortener/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	repo := repositories.NewMemoryAPIKeyRepository()
+	service := NewAPIKeyService(repo)
+
+	plaintext, key, err := service.CreateKey("ci", false)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(plaintext, apiKeyPrefix))
+	assert.NotContains(t, key.KeyHash, plaintext, "only the hash may be stored")
+	assert.True(t, strings.HasPrefix(plaintext, key.Prefix))
+
+	authenticated, err := service.Authenticate(plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, authenticated.ID)
+
+	_, err = service.Authenticate(plaintext + "x")
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+
+	require.NoError(t, service.RevokeKey(key.ID))
+	_, err = service.Authenticate(plaintext)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	assert.ErrorIs(t, service.RevokeKey(key.ID), ErrAPIKeyNotFound)
+
+	// EnsureKey is idempotent for configured keys
+	first, err := service.EnsureKey("configured-secret", "bootstrap", true)
+	require.NoError(t, err)
+	second, err := service.EnsureKey("configured-secret", "bootstrap", true)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, second.ID)
+}
+
+func TestCanModify(t *testing.T) {
+	ownerID := uint(2)
+	owned := &models.URL{OwnerID: &ownerID}
+	anonymous := &models.URL{}
+
+	assert.True(t, CanModify(&models.APIKey{ID: 2}, owned))
+	assert.False(t, CanModify(&models.APIKey{ID: 3}, owned))
+	assert.True(t, CanModify(&models.APIKey{ID: 3, IsAdmin: true}, owned))
+	assert.False(t, CanModify(&models.APIKey{ID: 2}, anonymous))
+	assert.True(t, CanModify(&models.APIKey{ID: 1, IsAdmin: true}, anonymous))
+	assert.False(t, CanModify(nil, owned))
+}
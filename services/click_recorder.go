@@ -0,0 +1,193 @@
+// services/click_recorder.go
+package services
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+	"url-shortener/logging"
+	"url-shortener/models"
+	"url-shortener/repositories"
+
+	"github.com/sirupsen/logrus"
+)
+
+// BackpressurePolicy decides what Enqueue does when the buffer is full.
+type BackpressurePolicy string
+
+const (
+	// BackpressureDrop discards the event and counts it as dropped.
+	BackpressureDrop BackpressurePolicy = "drop"
+	// BackpressureBlock waits for buffer space, slowing down the redirect.
+	BackpressureBlock BackpressurePolicy = "block"
+)
+
+var (
+	ErrClickDropped   = errors.New("click event dropped: buffer full")
+	ErrRecorderClosed = errors.New("click recorder is closed")
+)
+
+type ClickRecorderConfig struct {
+	BufferSize    int
+	BatchSize     int
+	FlushInterval time.Duration
+	Workers       int
+	Policy        BackpressurePolicy
+}
+
+// ClickRecorderStats are cumulative counters since the recorder started.
+type ClickRecorderStats struct {
+	Enqueued int64 `json:"enqueued"`
+	Dropped  int64 `json:"dropped"`
+	Written  int64 `json:"written"`
+	Failed   int64 `json:"failed"`
+	Queued   int   `json:"queued"`
+}
+
+// ClickRecorder takes click events off the redirect path. Events are buffered in a bounded
+// channel and written in batches by a pool of workers, each flushing when its batch is full
+// or FlushInterval has passed. Close flushes everything still buffered.
+type ClickRecorder struct {
+	clickRepo repositories.ClickRepository
+	config    ClickRecorderConfig
+	events    chan models.Click
+
+	mu     sync.RWMutex // Guards closed against concurrent Enqueue
+	closed bool
+	wg     sync.WaitGroup
+
+	enqueued atomic.Int64
+	dropped  atomic.Int64
+	written  atomic.Int64
+	failed   atomic.Int64
+}
+
+// NewClickRecorder starts the worker pool. Zero config values fall back to defaults.
+func NewClickRecorder(clickRepo repositories.ClickRepository, config ClickRecorderConfig) *ClickRecorder {
+	if config.BufferSize <= 0 {
+		config.BufferSize = 10000
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	if config.Workers <= 0 {
+		config.Workers = 2
+	}
+	if config.Policy != BackpressureBlock {
+		config.Policy = BackpressureDrop
+	}
+
+	r := &ClickRecorder{
+		clickRepo: clickRepo,
+		config:    config,
+		events:    make(chan models.Click, config.BufferSize),
+	}
+	for i := 0; i < config.Workers; i++ {
+		r.wg.Add(1)
+		go r.worker()
+	}
+	return r
+}
+
+// Enqueue hands a click to the workers, applying the backpressure policy if the buffer is full.
+func (r *ClickRecorder) Enqueue(click *models.Click) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.closed {
+		r.dropped.Add(1)
+		return ErrRecorderClosed
+	}
+
+	if r.config.Policy == BackpressureBlock {
+		r.events <- *click
+		r.enqueued.Add(1)
+		return nil
+	}
+
+	select {
+	case r.events <- *click:
+		r.enqueued.Add(1)
+		return nil
+	default:
+		r.dropped.Add(1)
+		return ErrClickDropped
+	}
+}
+
+// Close stops accepting events and blocks until every buffered event has been flushed.
+func (r *ClickRecorder) Close() {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+	r.closed = true
+	close(r.events)
+	r.mu.Unlock()
+
+	r.wg.Wait()
+	logging.Log.WithFields(r.logFields()).Info("Click recorder flushed and stopped")
+}
+
+func (r *ClickRecorder) Stats() ClickRecorderStats {
+	return ClickRecorderStats{
+		Enqueued: r.enqueued.Load(),
+		Dropped:  r.dropped.Load(),
+		Written:  r.written.Load(),
+		Failed:   r.failed.Load(),
+		Queued:   len(r.events),
+	}
+}
+
+func (r *ClickRecorder) worker() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.Click, 0, r.config.BatchSize)
+	for {
+		select {
+		case click, ok := <-r.events:
+			if !ok {
+				r.flush(batch)
+				return
+			}
+			batch = append(batch, click)
+			if len(batch) >= r.config.BatchSize {
+				r.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			r.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (r *ClickRecorder) flush(batch []models.Click) {
+	if len(batch) == 0 {
+		return
+	}
+	if err := r.clickRepo.CreateBatch(batch); err != nil {
+		r.failed.Add(int64(len(batch)))
+		logging.Log.WithError(err).WithField("batch_size", len(batch)).Error("Failed to write click batch")
+		return
+	}
+	r.written.Add(int64(len(batch)))
+}
+
+func (r *ClickRecorder) logFields() logrus.Fields {
+	stats := r.Stats()
+	return logrus.Fields{
+		"enqueued": stats.Enqueued,
+		"dropped":  stats.Dropped,
+		"written":  stats.Written,
+		"failed":   stats.Failed,
+	}
+}
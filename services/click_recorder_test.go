@@ -0,0 +1,105 @@
+package services
+
+import (
+	"sync"
+	"testing"
+	"time"
+	"url-shortener/models"
+	"url-shortener/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingClickRepository holds every batch write until release is closed.
+type blockingClickRepository struct {
+	repositories.ClickRepository
+	release chan struct{}
+	mu      sync.Mutex
+	batches [][]models.Click
+}
+
+func (r *blockingClickRepository) CreateBatch(clicks []models.Click) error {
+	<-r.release
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, append([]models.Click(nil), clicks...))
+	return nil
+}
+
+func TestClickRecorderFlushesOnBatchSizeAndClose(t *testing.T) {
+	repo := repositories.NewMemoryClickRepository()
+	recorder := NewClickRecorder(repo, ClickRecorderConfig{BatchSize: 5, FlushInterval: time.Hour, Workers: 1})
+
+	for i := 0; i < 12; i++ {
+		require.NoError(t, recorder.Enqueue(&models.Click{URLID: 1, ClickedAt: time.Now()}))
+	}
+
+	// Two full batches are written without waiting for the interval
+	assert.Eventually(t, func() bool { return recorder.Stats().Written == 10 }, time.Second, 5*time.Millisecond)
+
+	// The partial batch is flushed on Close
+	recorder.Close()
+	count, err := repo.CountByURLID(1)
+	require.NoError(t, err)
+	assert.EqualValues(t, 12, count)
+	assert.Equal(t, ClickRecorderStats{Enqueued: 12, Written: 12}, recorder.Stats())
+
+	assert.ErrorIs(t, recorder.Enqueue(&models.Click{URLID: 1}), ErrRecorderClosed)
+}
+
+func TestClickRecorderFlushesOnInterval(t *testing.T) {
+	repo := repositories.NewMemoryClickRepository()
+	recorder := NewClickRecorder(repo, ClickRecorderConfig{BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+	defer recorder.Close()
+
+	require.NoError(t, recorder.Enqueue(&models.Click{URLID: 7, ClickedAt: time.Now()}))
+	assert.Eventually(t, func() bool {
+		count, _ := repo.CountByURLID(7)
+		return count == 1
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestClickRecorderDropPolicy(t *testing.T) {
+	repo := &blockingClickRepository{release: make(chan struct{})}
+	recorder := NewClickRecorder(repo, ClickRecorderConfig{BufferSize: 2, BatchSize: 1, Workers: 1, Policy: BackpressureDrop})
+
+	// One event is held by the stuck worker and two fill the buffer; the rest are dropped
+	dropped := 0
+	for i := 0; i < 10; i++ {
+		if err := recorder.Enqueue(&models.Click{URLID: 1}); err != nil {
+			assert.ErrorIs(t, err, ErrClickDropped)
+			dropped++
+		}
+	}
+	assert.GreaterOrEqual(t, dropped, 7)
+	assert.EqualValues(t, dropped, recorder.Stats().Dropped)
+
+	close(repo.release)
+	recorder.Close()
+	assert.EqualValues(t, 10-dropped, recorder.Stats().Written)
+}
+
+func TestClickRecorderBlockPolicy(t *testing.T) {
+	repo := &blockingClickRepository{release: make(chan struct{})}
+	recorder := NewClickRecorder(repo, ClickRecorderConfig{BufferSize: 1, BatchSize: 1, Workers: 1, Policy: BackpressureBlock})
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			assert.NoError(t, recorder.Enqueue(&models.Click{URLID: 1}))
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Enqueue should block while the buffer is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(repo.release)
+	<-done
+	recorder.Close()
+	assert.Equal(t, ClickRecorderStats{Enqueued: 5, Written: 5}, recorder.Stats())
+}
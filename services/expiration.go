@@ -0,0 +1,50 @@
+// services/expiration.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"url-shortener/models"
+)
+
+// ErrExpirationTooLate is returned for expiration dates beyond ExpirationPolicy.MaxLifetime.
+var ErrExpirationTooLate = errors.New("expiration date exceeds the maximum link lifetime")
+
+// ExpirationPolicy decides when links expire. The zero value gives links DefaultExpiration
+// unless they ask for something else, without an upper limit.
+type ExpirationPolicy struct {
+	// DefaultTTL applies to links created without an expiration date, default
+	// DefaultExpiration. It is capped at MaxLifetime.
+	DefaultTTL time.Duration
+	// MaxLifetime bounds how far ahead a link may expire, counted from when it is created or
+	// updated. With a limit, links can't be made permanent. 0 means no limit.
+	MaxLifetime time.Duration
+}
+
+// expirationDate returns the date to store for a requested one: the default for a zero
+// date, ErrInvalidExpiration for dates that have passed and ErrExpirationTooLate for dates
+// the policy doesn't allow. models.NeverExpires requests a permanent link.
+func (p ExpirationPolicy) expirationDate(requested time.Time) (time.Time, error) {
+	now := time.Now()
+	if requested.IsZero() {
+		ttl := p.DefaultTTL
+		if ttl <= 0 {
+			ttl = DefaultExpiration
+		}
+		if p.MaxLifetime > 0 && ttl > p.MaxLifetime {
+			ttl = p.MaxLifetime
+		}
+		return now.Add(ttl), nil
+	}
+	if !requested.After(now) {
+		return time.Time{}, ErrInvalidExpiration
+	}
+	if p.MaxLifetime > 0 && requested.After(now.Add(p.MaxLifetime)) {
+		if !requested.Before(models.NeverExpires) {
+			return time.Time{}, fmt.Errorf("%w: links must expire within %s", ErrExpirationTooLate, p.MaxLifetime)
+		}
+		return time.Time{}, fmt.Errorf("%w of %s", ErrExpirationTooLate, p.MaxLifetime)
+	}
+	return requested, nil
+}
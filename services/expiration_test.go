@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+	"time"
+	"url-shortener/models"
+	"url-shortener/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpirationPolicy(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		policy    ExpirationPolicy
+		requested time.Time
+		want      time.Time
+		err       error
+	}{
+		{"default", ExpirationPolicy{}, time.Time{}, now.Add(DefaultExpiration), nil},
+		{"configured default", ExpirationPolicy{DefaultTTL: time.Hour}, time.Time{}, now.Add(time.Hour), nil},
+		{"default capped", ExpirationPolicy{DefaultTTL: 48 * time.Hour, MaxLifetime: time.Hour}, time.Time{}, now.Add(time.Hour), nil},
+		{"requested", ExpirationPolicy{}, now.Add(72 * time.Hour), now.Add(72 * time.Hour), nil},
+		{"never without limit", ExpirationPolicy{}, models.NeverExpires, models.NeverExpires, nil},
+		{"within limit", ExpirationPolicy{MaxLifetime: 30 * 24 * time.Hour}, now.Add(24 * time.Hour), now.Add(24 * time.Hour), nil},
+		{"beyond limit", ExpirationPolicy{MaxLifetime: 24 * time.Hour}, now.Add(48 * time.Hour), time.Time{}, ErrExpirationTooLate},
+		{"never with limit", ExpirationPolicy{MaxLifetime: 24 * time.Hour}, models.NeverExpires, time.Time{}, ErrExpirationTooLate},
+		{"past", ExpirationPolicy{}, now.Add(-time.Minute), time.Time{}, ErrInvalidExpiration},
+		{"now", ExpirationPolicy{MaxLifetime: time.Hour}, now, time.Time{}, ErrInvalidExpiration},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.policy.expirationDate(tt.requested)
+			assert.ErrorIs(t, err, tt.err)
+			assert.WithinDuration(t, tt.want, got, time.Minute)
+		})
+	}
+}
+
+func TestPermanentLinks(t *testing.T) {
+	service := NewURLService(repositories.NewMemoryURLRepository(), nil, nil, ExpirationPolicy{})
+	url, err := service.CreateURL(CreateURLParams{OriginalURL: "https://example.com", CustomSlug: "forever", ExpirationDate: models.NeverExpires})
+	require.NoError(t, err)
+	assert.False(t, url.Expires())
+
+	_, err = service.GetURL("forever")
+	assert.NoError(t, err)
+
+	limited := NewURLService(repositories.NewMemoryURLRepository(), nil, nil, ExpirationPolicy{MaxLifetime: time.Hour})
+	_, err = limited.CreateURL(CreateURLParams{OriginalURL: "https://example.com", ExpirationDate: models.NeverExpires})
+	assert.ErrorIs(t, err, ErrExpirationTooLate)
+	_, err = limited.CreateURL(CreateURLParams{OriginalURL: "https://example.com", CustomSlug: "short"})
+	require.NoError(t, err)
+	later := time.Now().Add(2 * time.Hour)
+	_, err = limited.UpdateURL("short", UpdateURLParams{ExpirationDate: &later}, &models.APIKey{IsAdmin: true})
+	assert.ErrorIs(t, err, ErrExpirationTooLate)
+}
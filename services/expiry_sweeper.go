@@ -0,0 +1,190 @@
+// services/expiry_sweeper.go
+package services
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+	"url-shortener/logging"
+	"url-shortener/repositories"
+	"url-shortener/utils"
+)
+
+// expirySweepLock is the lease whose holder sweeps; the other replicas stand by.
+const expirySweepLock = "expiry_sweeper"
+
+// ExpirySweeperConfig configures NewExpirySweeper. Zero values fall back to defaults.
+type ExpirySweeperConfig struct {
+	Interval   time.Duration // Time between sweeps, default 1m
+	BatchSize  int           // Links deleted per statement, default 500
+	MaxBatches int           // Batches per sweep, so one sweep can't run for long; default 20
+	// LeaseTTL is how long leadership outlives a replica that stopped sweeping without
+	// releasing it. Default three intervals.
+	LeaseTTL time.Duration
+}
+
+// SweepReport counts what one sweep did.
+type SweepReport struct {
+	Expired      int64 `json:"expired"` // Links deleted because they expired
+	PurgedLinks  int   `json:"purgedLinks"`
+	PurgedClicks int64 `json:"purgedClicks"`
+}
+
+// ExpirySweeperStats are cumulative counters since the sweeper started.
+type ExpirySweeperStats struct {
+	Leader       bool      `json:"leader"`
+	Sweeps       int64     `json:"sweeps"`
+	Failed       int64     `json:"failed"`
+	Expired      int64     `json:"expired"`
+	PurgedLinks  int64     `json:"purgedLinks"`
+	PurgedClicks int64     `json:"purgedClicks"`
+	LastSweep    time.Time `json:"lastSweep"`
+}
+
+// ExpirySweeper deletes expired links in the background rather than waiting for someone to
+// follow them, and purges tombstones whose period has passed. Replicas compete for a lease
+// each interval; only the holder sweeps.
+type ExpirySweeper struct {
+	urlRepo   repositories.URLRepository
+	lifecycle LinkLifecycleService
+	locks     repositories.LockRepository
+	owner     string
+	config    ExpirySweeperConfig
+
+	stop      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+
+	leader       atomic.Bool
+	sweeps       atomic.Int64
+	failed       atomic.Int64
+	expired      atomic.Int64
+	purgedLinks  atomic.Int64
+	purgedClicks atomic.Int64
+	lastSweep    atomic.Int64 // Unix nanoseconds, 0 before the first sweep
+}
+
+// NewExpirySweeper creates a sweeper; Start runs it in the background.
+func NewExpirySweeper(urlRepo repositories.URLRepository, lifecycle LinkLifecycleService, locks repositories.LockRepository, config ExpirySweeperConfig) *ExpirySweeper {
+	if config.Interval <= 0 {
+		config.Interval = time.Minute
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 500
+	}
+	if config.MaxBatches <= 0 {
+		config.MaxBatches = 20
+	}
+	if config.LeaseTTL <= 0 {
+		config.LeaseTTL = 3 * config.Interval
+	}
+	return &ExpirySweeper{
+		urlRepo:   urlRepo,
+		lifecycle: lifecycle,
+		locks:     locks,
+		owner:     utils.InstanceID(),
+		config:    config,
+		stop:      make(chan struct{}),
+	}
+}
+
+// Start sweeps right away and then every interval, whenever this replica holds the lease.
+func (s *ExpirySweeper) Start() {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.config.Interval)
+		defer ticker.Stop()
+		for {
+			s.tick()
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Close waits for a running sweep to finish and hands the lease to another replica.
+func (s *ExpirySweeper) Close() {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		s.wg.Wait()
+		if s.leader.Load() {
+			if err := s.locks.Release(expirySweepLock, s.owner); err != nil {
+				logging.Log.WithError(err).Warn("Failed to release expiry sweeper lease")
+			}
+			s.leader.Store(false)
+		}
+	})
+}
+
+// Sweep deletes up to MaxBatches batches of expired links, then purges tombstones. It does
+// not take the lease; Start does that before each sweep.
+func (s *ExpirySweeper) Sweep() (*SweepReport, error) {
+	report := &SweepReport{}
+	now := time.Now()
+	for batch := 0; batch < s.config.MaxBatches; batch++ {
+		deleted, err := s.urlRepo.DeleteExpired(now, s.config.BatchSize)
+		report.Expired += int64(len(deleted))
+		if err != nil {
+			return report, err
+		}
+		if len(deleted) < s.config.BatchSize {
+			break
+		}
+	}
+
+	purged, err := s.lifecycle.Purge()
+	if purged != nil {
+		report.PurgedLinks, report.PurgedClicks = purged.Links, purged.Clicks
+	}
+	return report, err
+}
+
+func (s *ExpirySweeper) Stats() ExpirySweeperStats {
+	stats := ExpirySweeperStats{
+		Leader:       s.leader.Load(),
+		Sweeps:       s.sweeps.Load(),
+		Failed:       s.failed.Load(),
+		Expired:      s.expired.Load(),
+		PurgedLinks:  s.purgedLinks.Load(),
+		PurgedClicks: s.purgedClicks.Load(),
+	}
+	if last := s.lastSweep.Load(); last != 0 {
+		stats.LastSweep = time.Unix(0, last)
+	}
+	return stats
+}
+
+// tick takes or renews the lease and sweeps if it got it.
+func (s *ExpirySweeper) tick() {
+	acquired, err := s.locks.TryAcquire(expirySweepLock, s.owner, s.config.LeaseTTL)
+	if err != nil {
+		s.failed.Add(1)
+		logging.Log.WithError(err).Error("Failed to acquire expiry sweeper lease")
+		return
+	}
+	if was := s.leader.Swap(acquired); was != acquired {
+		logging.Log.WithField("leader", acquired).Info("Expiry sweeper leadership changed")
+	}
+	if !acquired {
+		return
+	}
+
+	report, err := s.Sweep()
+	s.sweeps.Add(1)
+	s.lastSweep.Store(time.Now().UnixNano())
+	s.expired.Add(report.Expired)
+	s.purgedLinks.Add(int64(report.PurgedLinks))
+	s.purgedClicks.Add(report.PurgedClicks)
+	if err != nil {
+		s.failed.Add(1)
+		logging.Log.WithError(err).Error("Expiry sweep failed")
+		return
+	}
+	if report.Expired > 0 {
+		logging.Log.WithField("expired", report.Expired).Info("Deleted expired links")
+	}
+}
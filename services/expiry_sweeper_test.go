@@ -0,0 +1,63 @@
+package services
+
+import (
+	"testing"
+	"time"
+	"url-shortener/models"
+	"url-shortener/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpirySweep(t *testing.T) {
+	urlRepo := repositories.NewMemoryURLRepository()
+	lifecycle := NewLinkLifecycleService(urlRepo, repositories.NewMemoryClickRepository(), LinkLifecycleConfig{TombstonePeriod: time.Hour})
+	sweeper := NewExpirySweeper(urlRepo, lifecycle, repositories.NewMemoryLockRepository(), ExpirySweeperConfig{BatchSize: 2, MaxBatches: 2})
+
+	for i, expires := range []time.Duration{-time.Minute, -time.Minute, -time.Minute, -time.Minute, -time.Minute, -2 * time.Hour, time.Hour} {
+		require.NoError(t, urlRepo.Create(&models.URL{
+			OriginalURL:    "https://example.com",
+			ShortLink:      string(rune('a'+i)) + "link",
+			ExpirationDate: time.Now().Add(expires),
+		}))
+	}
+
+	// Bounded: two batches of two, and the link past its tombstone period is purged
+	report, err := sweeper.Sweep()
+	require.NoError(t, err)
+	assert.Equal(t, &SweepReport{Expired: 4, PurgedLinks: 1}, report)
+
+	report, err = sweeper.Sweep()
+	require.NoError(t, err)
+	assert.Equal(t, &SweepReport{Expired: 1}, report)
+
+	_, err = urlRepo.FindByShortLink("glink")
+	assert.NoError(t, err, "live links are left alone")
+	_, err = urlRepo.FindDeletedByShortLink("alink")
+	assert.NoError(t, err, "expired links become tombstones")
+}
+
+func TestExpirySweeperLeadership(t *testing.T) {
+	urlRepo := repositories.NewMemoryURLRepository()
+	lifecycle := NewLinkLifecycleService(urlRepo, repositories.NewMemoryClickRepository(), LinkLifecycleConfig{})
+	locks := repositories.NewMemoryLockRepository()
+	first := NewExpirySweeper(urlRepo, lifecycle, locks, ExpirySweeperConfig{})
+	second := NewExpirySweeper(urlRepo, lifecycle, locks, ExpirySweeperConfig{})
+	second.owner = "other-replica"
+
+	first.tick()
+	second.tick()
+	assert.True(t, first.Stats().Leader)
+	assert.EqualValues(t, 1, first.Stats().Sweeps)
+	assert.False(t, second.Stats().Leader)
+	assert.Zero(t, second.Stats().Sweeps)
+
+	// Closing hands the lease over
+	first.Close()
+	second.tick()
+	assert.False(t, first.Stats().Leader)
+	assert.True(t, second.Stats().Leader)
+	assert.EqualValues(t, 1, second.Stats().Sweeps)
+	second.Close()
+}
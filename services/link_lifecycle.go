@@ -0,0 +1,130 @@
+// services/link_lifecycle.go
+package services
+
+import (
+	"errors"
+	"time"
+	"url-shortener/logging"
+	"url-shortener/models"
+	"url-shortener/repositories"
+)
+
+var (
+	ErrNotDeleted     = errors.New("link is not deleted")
+	ErrRestoreExpired = errors.New("link was deleted or expired too long ago to restore")
+)
+
+// LinkLifecycleConfig configures NewLinkLifecycleService. Zero values fall back to defaults.
+type LinkLifecycleConfig struct {
+	// TombstonePeriod is how long a deleted or expired link keeps its slug reserved and can
+	// be restored. Default 7 days.
+	TombstonePeriod time.Duration
+	// BatchSize is how many links Purge removes per round trip, default 500.
+	BatchSize int
+}
+
+// PurgeReport counts what a purge removed.
+type PurgeReport struct {
+	Links  int   `json:"links"`
+	Clicks int64 `json:"clicks"`
+}
+
+// LinkLifecycleService handles links after they are deleted. A deleted link is kept as a
+// tombstone: its slug stays taken and its owner can restore it. Once the tombstone period
+// has passed, Purge removes it for good and its slug can be claimed again. Expired links are
+// purged on the same schedule, counted from their expiration date.
+type LinkLifecycleService interface {
+	RestoreURL(shortLink string, actor *models.APIKey) (*models.URL, error)
+	Purge() (*PurgeReport, error)
+}
+
+type linkLifecycleService struct {
+	urlRepo   repositories.URLRepository
+	clickRepo repositories.ClickRepository
+	config    LinkLifecycleConfig
+}
+
+func NewLinkLifecycleService(urlRepo repositories.URLRepository, clickRepo repositories.ClickRepository, config LinkLifecycleConfig) LinkLifecycleService {
+	if config.TombstonePeriod <= 0 {
+		config.TombstonePeriod = 7 * 24 * time.Hour
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 500
+	}
+	return &linkLifecycleService{urlRepo: urlRepo, clickRepo: clickRepo, config: config}
+}
+
+// RestoreURL undeletes a link within the tombstone period, which for a link that expired
+// starts at its expiration date. Restored links keep their expiration date, so an expired one
+// needs a new date before it redirects again.
+func (s *linkLifecycleService) RestoreURL(shortLink string, actor *models.APIKey) (*models.URL, error) {
+	url, err := s.urlRepo.FindDeletedByShortLink(shortLink)
+	if errors.Is(err, repositories.ErrNotFound) {
+		if _, err := s.urlRepo.FindByShortLink(shortLink); err == nil {
+			return nil, ErrNotDeleted
+		}
+		return nil, ErrURLNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !CanModify(actor, url) {
+		return nil, ErrForbidden
+	}
+	// Purge may remove it at any moment, even restored
+	if cutoff := s.cutoff(); url.DeletedAt.Time.Before(cutoff) || url.ExpirationDate.Before(cutoff) {
+		return nil, ErrRestoreExpired
+	}
+
+	if err := s.urlRepo.Restore(url); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrURLNotFound // Purged or restored concurrently
+		}
+		return nil, err
+	}
+	return url, nil
+}
+
+// Purge hard-deletes every link whose tombstone period has passed, along with its clicks.
+// It works in batches, so a failure part way leaves earlier batches purged.
+func (s *linkLifecycleService) Purge() (*PurgeReport, error) {
+	report := &PurgeReport{}
+	cutoff := s.cutoff()
+	for {
+		urls, err := s.urlRepo.FindPurgeable(cutoff, s.config.BatchSize)
+		if err != nil {
+			return report, err
+		}
+		if len(urls) == 0 {
+			break
+		}
+
+		ids := make([]uint, len(urls))
+		for i, url := range urls {
+			ids[i] = url.ID
+		}
+		// Clicks first: if purging the links fails, the next run still finds them
+		clicks, err := s.clickRepo.DeleteByURLIDs(ids)
+		if err != nil {
+			return report, err
+		}
+		report.Clicks += clicks
+		if err := s.urlRepo.Purge(urls); err != nil {
+			return report, err
+		}
+		report.Links += len(urls)
+
+		if len(urls) < s.config.BatchSize {
+			break
+		}
+	}
+
+	if report.Links > 0 {
+		logging.Log.WithField("links", report.Links).WithField("clicks", report.Clicks).Info("Purged links")
+	}
+	return report, nil
+}
+
+func (s *linkLifecycleService) cutoff() time.Time {
+	return time.Now().Add(-s.config.TombstonePeriod)
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"testing"
+	"time"
+	"url-shortener/models"
+	"url-shortener/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestoreURL(t *testing.T) {
+	urlRepo := repositories.NewMemoryURLRepository()
+	urls := NewURLService(urlRepo, nil, nil, ExpirationPolicy{})
+	lifecycle := NewLinkLifecycleService(urlRepo, repositories.NewMemoryClickRepository(), LinkLifecycleConfig{})
+	owner := &models.APIKey{ID: 1}
+	_, err := urls.CreateURL(CreateURLParams{OriginalURL: "https://example.com", CustomSlug: "oops", Owner: owner})
+	require.NoError(t, err)
+
+	_, err = lifecycle.RestoreURL("oops", owner)
+	assert.ErrorIs(t, err, ErrNotDeleted)
+	_, err = lifecycle.RestoreURL("missing", owner)
+	assert.ErrorIs(t, err, ErrURLNotFound)
+
+	require.NoError(t, urls.DeleteURL("oops", owner))
+	_, err = urls.CreateURL(CreateURLParams{OriginalURL: "https://example.org", CustomSlug: "oops"})
+	assert.ErrorIs(t, err, ErrSlugTaken, "a tombstone holds its slug")
+	_, err = lifecycle.RestoreURL("oops", &models.APIKey{ID: 2})
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	url, err := lifecycle.RestoreURL("oops", owner)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", url.OriginalURL)
+	_, err = urls.GetURL("oops")
+	assert.NoError(t, err)
+}
+
+func TestRestoreURLAfterTombstonePeriod(t *testing.T) {
+	urlRepo := repositories.NewMemoryURLRepository()
+	urls := NewURLService(urlRepo, nil, nil, ExpirationPolicy{})
+	lifecycle := NewLinkLifecycleService(urlRepo, repositories.NewMemoryClickRepository(), LinkLifecycleConfig{TombstonePeriod: time.Nanosecond})
+	_, err := urls.CreateURL(CreateURLParams{OriginalURL: "https://example.com", CustomSlug: "late"})
+	require.NoError(t, err)
+	require.NoError(t, urls.DeleteURL("late", &models.APIKey{IsAdmin: true}))
+
+	time.Sleep(time.Millisecond)
+	_, err = lifecycle.RestoreURL("late", &models.APIKey{IsAdmin: true})
+	assert.ErrorIs(t, err, ErrRestoreExpired)
+}
+
+func TestRestoreURLExpiredBeforeTombstonePeriod(t *testing.T) {
+	urlRepo := repositories.NewMemoryURLRepository()
+	lifecycle := NewLinkLifecycleService(urlRepo, repositories.NewMemoryClickRepository(), LinkLifecycleConfig{TombstonePeriod: time.Hour})
+	url := &models.URL{OriginalURL: "https://example.com", ShortLink: "stale", ExpirationDate: time.Now().Add(-2 * time.Hour)}
+	require.NoError(t, urlRepo.Create(url))
+	require.NoError(t, urlRepo.Delete(url)) // Deleted just now, but purgeable by its expiration
+
+	_, err := lifecycle.RestoreURL("stale", &models.APIKey{IsAdmin: true})
+	assert.ErrorIs(t, err, ErrRestoreExpired)
+	_, err = urlRepo.FindDeletedByShortLink("stale")
+	assert.NoError(t, err, "the link stays deleted")
+}
+
+func TestPurge(t *testing.T) {
+	urlRepo := repositories.NewMemoryURLRepository()
+	clickRepo := repositories.NewMemoryClickRepository()
+	urls := NewURLService(urlRepo, nil, nil, ExpirationPolicy{})
+	lifecycle := NewLinkLifecycleService(urlRepo, clickRepo, LinkLifecycleConfig{TombstonePeriod: time.Hour, BatchSize: 2})
+	admin := &models.APIKey{IsAdmin: true}
+
+	// Stored directly, since links can't be created already expired
+	create := func(slug string, expires time.Time) *models.URL {
+		url := &models.URL{OriginalURL: "https://example.com/" + slug, ShortLink: slug, ExpirationDate: expires}
+		require.NoError(t, urlRepo.Create(url))
+		require.NoError(t, clickRepo.Create(&models.Click{URLID: url.ID, ClickedAt: time.Now()}))
+		return url
+	}
+	for _, slug := range []string{"old1", "old2", "old3"} {
+		create(slug, time.Now().Add(-2*time.Hour))
+	}
+	create("recent", time.Now().Add(-time.Minute))
+	create("deleted", time.Now().Add(time.Hour))
+	require.NoError(t, urls.DeleteURL("deleted", admin))
+	live := create("live", time.Now().Add(time.Hour))
+
+	report, err := lifecycle.Purge()
+	require.NoError(t, err)
+	assert.Equal(t, &PurgeReport{Links: 3, Clicks: 3}, report)
+
+	// Purged slugs are free again; tombstones and live links are untouched
+	_, err = urls.CreateURL(CreateURLParams{OriginalURL: "https://example.com", CustomSlug: "old1"})
+	assert.NoError(t, err)
+	for _, slug := range []string{"recent", "deleted", "live"} {
+		available, err := urls.CheckSlugAvailability(slug)
+		require.NoError(t, err)
+		assert.False(t, available.Available, slug)
+	}
+	count, err := clickRepo.CountByURLID(live.ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+}
@@ -0,0 +1,376 @@
+// services/link_transfer.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"url-shortener/models"
+	"url-shortener/repositories"
+)
+
+// TransferFormat is the file format of link exports and imports.
+type TransferFormat string
+
+const (
+	FormatCSV   TransferFormat = "csv"
+	FormatJSONL TransferFormat = "jsonl" // One JSON object per line
+)
+
+// ConflictPolicy decides what an import does with a row whose short link already exists.
+type ConflictPolicy string
+
+const (
+	ConflictSkip      ConflictPolicy = "skip"
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	ConflictRename    ConflictPolicy = "rename"
+)
+
+// ImportAction is what an import did (or, in a dry run, would do) with a row.
+type ImportAction string
+
+const (
+	ImportCreated     ImportAction = "created"
+	ImportOverwritten ImportAction = "overwritten"
+	ImportRenamed     ImportAction = "renamed"
+	ImportSkipped     ImportAction = "skipped"
+	ImportFailed      ImportAction = "failed"
+)
+
+// ErrInvalidTransfer wraps problems with an import or export request itself, as opposed to
+// problems with individual rows.
+var ErrInvalidTransfer = errors.New("invalid import or export")
+
+// ErrSlugDeleted fails rows that would overwrite a deleted or expired link. Its owner can
+// restore it first.
+var ErrSlugDeleted = errors.New("short link belongs to a deleted or expired link")
+
+// exportBatchSize is how many links an export reads from the repository at a time.
+const exportBatchSize = 500
+
+// LinkRecord is one link in an export. Imports read the same fields; id, destinationHost
+// and updatedAt are ignored there because they are derived by the server.
+type LinkRecord struct {
+	ID              uint       `json:"id"`
+	ShortLink       string     `json:"shortLink"`
+	OriginalURL     string     `json:"originalUrl"`
+	DestinationHost string     `json:"destinationHost"`
+	ExpirationDate  time.Time  `json:"expirationDate"`
+	OwnerID         *uint      `json:"ownerId"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+	ActivatesAt     *time.Time `json:"activatesAt,omitempty"`
+	FallbackURL     string     `json:"fallbackUrl,omitempty"`
+}
+
+type ImportOptions struct {
+	Format   TransferFormat
+	Conflict ConflictPolicy // Defaults to ConflictSkip
+	DryRun   bool           // Validate and report without writing anything
+}
+
+// ImportRow reports on one row of an import file. Line is the row's line number in the file.
+type ImportRow struct {
+	Line        int
+	ShortLink   string
+	Action      ImportAction
+	RenamedFrom string
+	Error       string
+}
+
+// ImportReport counts the outcome of every row. Rows lists each row that wasn't simply
+// created: failures, skips, renames and overwrites.
+type ImportReport struct {
+	DryRun      bool
+	Created     int
+	Overwritten int
+	Renamed     int
+	Skipped     int
+	Failed      int
+	Rows        []ImportRow
+}
+
+func (report *ImportReport) add(row ImportRow) {
+	switch row.Action {
+	case ImportCreated:
+		report.Created++
+		return
+	case ImportOverwritten:
+		report.Overwritten++
+	case ImportRenamed:
+		report.Renamed++
+	case ImportSkipped:
+		report.Skipped++
+	case ImportFailed:
+		report.Failed++
+	}
+	report.Rows = append(report.Rows, row)
+}
+
+// LinkTransferService moves links in and out of the store in portable formats. Like
+// ListURLs, non-admin keys only export their own links, and links they import are theirs.
+type LinkTransferService interface {
+	Export(w io.Writer, format TransferFormat, filter LinkFilter, actor *models.APIKey) error
+	Import(r io.Reader, options ImportOptions, actor *models.APIKey) (*ImportReport, error)
+}
+
+type linkTransferService struct {
+	urlRepo  repositories.URLRepository
+	slugs    SlugGenerator
+	reserved *SlugRegistry
+	expiry   ExpirationPolicy
+}
+
+// NewLinkTransferService uses slugs for rows without a short link; nil selects a
+// RandomSlugGenerator with default settings. Rows may not claim slugs in reserved, which
+// defaults to the built-in names, nor expire later than expiry allows.
+func NewLinkTransferService(urlRepo repositories.URLRepository, slugs SlugGenerator, reserved *SlugRegistry, expiry ExpirationPolicy) LinkTransferService {
+	return &linkTransferService{urlRepo: urlRepo, slugs: orDefaultSlugGenerator(slugs), reserved: orDefaultSlugRegistry(reserved), expiry: expiry}
+}
+
+// ParseTransferFormat validates a format name.
+func ParseTransferFormat(value string) (TransferFormat, error) {
+	switch format := TransferFormat(strings.ToLower(value)); format {
+	case FormatCSV, FormatJSONL:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: format must be csv or jsonl", ErrInvalidTransfer)
+	}
+}
+
+// ParseConflictPolicy validates a conflict policy name; empty selects ConflictSkip.
+func ParseConflictPolicy(value string) (ConflictPolicy, error) {
+	switch policy := ConflictPolicy(strings.ToLower(value)); policy {
+	case "":
+		return ConflictSkip, nil
+	case ConflictSkip, ConflictOverwrite, ConflictRename:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("%w: conflict policy must be skip, overwrite or rename", ErrInvalidTransfer)
+	}
+}
+
+// Export streams every link matching filter to w, oldest first. Nothing is written if the
+// request is invalid.
+func (s *linkTransferService) Export(w io.Writer, format TransferFormat, filter LinkFilter, actor *models.APIKey) error {
+	query, err := filterQuery(filter, actor)
+	if err != nil {
+		return err
+	}
+	if _, err := ParseTransferFormat(string(format)); err != nil {
+		return err
+	}
+	query.SortBy = repositories.SortByCreatedAt
+	query.Limit = exportBatchSize
+
+	var writer recordWriter
+	for {
+		urls, err := s.urlRepo.List(query)
+		if err != nil {
+			return err
+		}
+		if writer == nil {
+			// Deferred until the first read succeeds so a failing store produces no output
+			writer = newRecordWriter(w, format)
+		}
+		for i := range urls {
+			if err := writer.Write(toLinkRecord(&urls[i])); err != nil {
+				return err
+			}
+		}
+		if len(urls) < exportBatchSize {
+			return writer.Flush()
+		}
+		cursor := repositories.CursorFor(&urls[len(urls)-1], query.SortBy)
+		query.After = &cursor
+	}
+}
+
+// Import creates links from r. Rows are processed independently: a bad row is reported
+// and skipped, and only an unreadable file or a storage failure stops the import.
+func (s *linkTransferService) Import(r io.Reader, options ImportOptions, actor *models.APIKey) (*ImportReport, error) {
+	if actor == nil {
+		return nil, ErrForbidden
+	}
+	if options.Conflict == "" {
+		options.Conflict = ConflictSkip
+	}
+	if _, err := ParseConflictPolicy(string(options.Conflict)); err != nil {
+		return nil, err
+	}
+	reader, err := newRecordReader(r, options.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{DryRun: options.DryRun, Rows: []ImportRow{}}
+	// Slugs taken by earlier rows, which a dry run can't see in the store
+	claimed := make(map[string]bool)
+	for {
+		line, record, err := reader.Next()
+		if err == io.EOF {
+			return report, nil
+		}
+		var rowErr *rowError
+		if errors.As(err, &rowErr) {
+			report.add(ImportRow{Line: line, Action: ImportFailed, Error: rowErr.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row, err := s.importRecord(record, options, actor, claimed)
+		if err != nil {
+			return nil, err
+		}
+		row.Line = line
+		report.add(row)
+	}
+}
+
+// importRecord applies one parsed row. Problems with the row are reported in the returned
+// ImportRow; the error is reserved for storage failures.
+func (s *linkTransferService) importRecord(record importRecord, options ImportOptions, actor *models.APIKey, claimed map[string]bool) (ImportRow, error) {
+	row := ImportRow{ShortLink: record.ShortLink}
+	failed := func(err error) (ImportRow, error) {
+		row.Action, row.Error = ImportFailed, err.Error()
+		return row, nil
+	}
+
+	url, err := record.toURL(actor, s.expiry)
+	if err != nil {
+		return failed(err)
+	}
+
+	if url.ShortLink == "" {
+		if url.ShortLink, err = generateSlug(s.slugs, slugTaken(s.urlRepo, claimed), s.reserved); err != nil {
+			return row, err
+		}
+		return s.createImported(row, url, options, claimed)
+	}
+	if storedSlugReason(url.ShortLink) != "" {
+		return failed(ErrSlugInvalid)
+	}
+	reserved, err := s.reserved.IsReserved(url.ShortLink)
+	if err != nil {
+		return row, err
+	}
+	if reserved {
+		return failed(ErrSlugReserved)
+	}
+
+	existing, err := s.urlRepo.FindByShortLink(url.ShortLink)
+	if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+		return row, err
+	}
+	taken := existing != nil || claimed[url.ShortLink]
+	if !taken {
+		// Tombstones hold their slug too
+		if taken, err = s.urlRepo.ExistsByShortLink(url.ShortLink); err != nil {
+			return row, err
+		}
+	}
+	if !taken {
+		return s.createImported(row, url, options, claimed)
+	}
+
+	switch options.Conflict {
+	case ConflictOverwrite:
+		if existing == nil && !claimed[url.ShortLink] {
+			return failed(ErrSlugDeleted)
+		}
+		if existing != nil {
+			if !CanModify(actor, existing) {
+				return failed(ErrForbidden)
+			}
+			existing.OriginalURL = url.OriginalURL
+			existing.DestinationHost = url.DestinationHost
+			existing.ExpirationDate = url.ExpirationDate
+			existing.ActivatesAt = url.ActivatesAt
+			existing.FallbackURL = url.FallbackURL
+			if actor.IsAdmin && record.OwnerID != nil {
+				existing.OwnerID = record.OwnerID
+			}
+			if !options.DryRun {
+				if err := s.urlRepo.Update(existing); err != nil {
+					return row, err
+				}
+			}
+		}
+		row.Action = ImportOverwritten
+		return row, nil
+	case ConflictRename:
+		renamed, err := s.renameSlug(url.ShortLink, claimed)
+		if err != nil {
+			return row, err
+		}
+		row.RenamedFrom, url.ShortLink = url.ShortLink, renamed
+		row, err = s.createImported(row, url, options, claimed)
+		if row.Action == ImportCreated {
+			row.Action = ImportRenamed
+		}
+		return row, err
+	default:
+		row.Action = ImportSkipped
+		return row, nil
+	}
+}
+
+func (s *linkTransferService) createImported(row ImportRow, url *models.URL, options ImportOptions, claimed map[string]bool) (ImportRow, error) {
+	row.ShortLink = url.ShortLink
+	if !options.DryRun {
+		err := s.urlRepo.Create(url)
+		if errors.Is(err, repositories.ErrDuplicateShortLink) {
+			// Claimed since it was checked
+			row.Action, row.Error = ImportFailed, ErrSlugTaken.Error()
+			return row, nil
+		}
+		if err != nil {
+			return row, err
+		}
+	}
+	claimed[url.ShortLink] = true
+	row.Action = ImportCreated
+	return row, nil
+}
+
+// renameSlug prefers a close variant of slug, like the availability suggestions, and falls
+// back to a random slug.
+func (s *linkTransferService) renameSlug(slug string, claimed map[string]bool) (string, error) {
+	for _, candidate := range slugCandidates(slug) {
+		reserved, err := s.reserved.IsReserved(candidate)
+		if err != nil {
+			return "", err
+		}
+		exists, err := s.urlRepo.ExistsByShortLink(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists && !reserved && !claimed[candidate] {
+			return candidate, nil
+		}
+	}
+	return generateSlug(s.slugs, slugTaken(s.urlRepo, claimed), s.reserved)
+}
+
+func toLinkRecord(url *models.URL) LinkRecord {
+	record := LinkRecord{
+		ID:              url.ID,
+		ShortLink:       url.ShortLink,
+		OriginalURL:     url.OriginalURL,
+		DestinationHost: url.DestinationHost,
+		ExpirationDate:  url.ExpirationDate.UTC(),
+		OwnerID:         url.OwnerID,
+		CreatedAt:       url.CreatedAt.UTC(),
+		UpdatedAt:       url.UpdatedAt.UTC(),
+		FallbackURL:     url.FallbackURL,
+	}
+	if url.ActivatesAt != nil {
+		activatesAt := url.ActivatesAt.UTC()
+		record.ActivatesAt = &activatesAt
+	}
+	return record
+}
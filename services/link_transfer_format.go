@@ -0,0 +1,264 @@
+// services/link_transfer_format.go
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"url-shortener/models"
+	"url-shortener/utils"
+)
+
+// csvColumns is the header of CSV exports, matching the JSON field names of LinkRecord.
+var csvColumns = []string{"id", "shortLink", "originalUrl", "destinationHost", "expirationDate", "ownerId", "createdAt", "updatedAt", "activatesAt", "fallbackUrl"}
+
+// maxJSONLLine bounds a single JSON Lines record.
+const maxJSONLLine = 1 << 20
+
+type recordWriter interface {
+	Write(record LinkRecord) error
+	Flush() error
+}
+
+func newRecordWriter(w io.Writer, format TransferFormat) recordWriter {
+	if format == FormatCSV {
+		return &csvRecordWriter{w: csv.NewWriter(w)}
+	}
+	return &jsonlRecordWriter{enc: json.NewEncoder(w)}
+}
+
+type jsonlRecordWriter struct {
+	enc *json.Encoder
+}
+
+func (w *jsonlRecordWriter) Write(record LinkRecord) error {
+	return w.enc.Encode(record)
+}
+
+func (w *jsonlRecordWriter) Flush() error {
+	return nil
+}
+
+type csvRecordWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (w *csvRecordWriter) Write(record LinkRecord) error {
+	if !w.headerWritten {
+		if err := w.w.Write(csvColumns); err != nil {
+			return err
+		}
+		w.headerWritten = true
+	}
+	ownerID := ""
+	if record.OwnerID != nil {
+		ownerID = strconv.FormatUint(uint64(*record.OwnerID), 10)
+	}
+	activatesAt := ""
+	if record.ActivatesAt != nil {
+		activatesAt = record.ActivatesAt.Format(time.RFC3339Nano)
+	}
+	return w.w.Write([]string{
+		strconv.FormatUint(uint64(record.ID), 10),
+		record.ShortLink,
+		record.OriginalURL,
+		record.DestinationHost,
+		record.ExpirationDate.Format(time.RFC3339Nano),
+		ownerID,
+		record.CreatedAt.Format(time.RFC3339Nano),
+		record.UpdatedAt.Format(time.RFC3339Nano),
+		activatesAt,
+		record.FallbackURL,
+	})
+}
+
+func (w *csvRecordWriter) Flush() error {
+	// An empty export still gets a header so the file is self-describing
+	if !w.headerWritten {
+		if err := w.w.Write(csvColumns); err != nil {
+			return err
+		}
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// importRecord is a row as read from an import file, before validation.
+type importRecord struct {
+	ShortLink      string `json:"shortLink"`
+	OriginalURL    string `json:"originalUrl"`
+	ExpirationDate string `json:"expirationDate"` // RFC 3339 or YYYY-MM-DD; empty for the default
+	OwnerID        *uint  `json:"ownerId"`        // Only honoured for admins
+	CreatedAt      string `json:"createdAt"`
+	ActivatesAt    string `json:"activatesAt"`
+	FallbackURL    string `json:"fallbackUrl"`
+}
+
+// toURL validates the record and builds the link it describes, owned by actor unless an
+// admin imports rows that name their owner. Expiration dates are subject to expiry.
+func (record importRecord) toURL(actor *models.APIKey, expiry ExpirationPolicy) (*models.URL, error) {
+	if !isHTTPURL(record.OriginalURL) || record.FallbackURL != "" && !isHTTPURL(record.FallbackURL) {
+		return nil, ErrInvalidURL
+	}
+	expirationDate, err := utils.ParseTimestamp(record.ExpirationDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expirationDate %q", record.ExpirationDate)
+	}
+	if expirationDate, err = expiry.expirationDate(expirationDate); err != nil {
+		return nil, err
+	}
+	createdAt, err := utils.ParseTimestamp(record.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid createdAt %q", record.CreatedAt)
+	}
+	activatesAt, err := utils.ParseTimestamp(record.ActivatesAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid activatesAt %q", record.ActivatesAt)
+	}
+	if !activatesAt.IsZero() && !activatesAt.Before(expirationDate) {
+		return nil, ErrInvalidActivation
+	}
+
+	url := &models.URL{
+		OriginalURL:     record.OriginalURL,
+		ShortLink:       strings.TrimSpace(record.ShortLink),
+		ExpirationDate:  expirationDate,
+		DestinationHost: destinationHost(record.OriginalURL),
+		FallbackURL:     record.FallbackURL,
+	}
+	url.CreatedAt = createdAt
+	if !activatesAt.IsZero() {
+		url.ActivatesAt = &activatesAt
+	}
+	if actor.IsAdmin {
+		url.OwnerID = record.OwnerID
+	} else {
+		url.OwnerID = &actor.ID
+	}
+	return url, nil
+}
+
+// rowError marks a row that can't be parsed; the import reports it and carries on.
+type rowError struct {
+	err error
+}
+
+func (e *rowError) Error() string {
+	return e.err.Error()
+}
+
+type recordReader interface {
+	// Next returns the next record and its line number, or io.EOF after the last one.
+	Next() (int, importRecord, error)
+}
+
+func newRecordReader(r io.Reader, format TransferFormat) (recordReader, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVRecordReader(r)
+	case FormatJSONL:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxJSONLLine)
+		return &jsonlRecordReader{scanner: scanner}, nil
+	default:
+		return nil, fmt.Errorf("%w: format must be csv or jsonl", ErrInvalidTransfer)
+	}
+}
+
+type jsonlRecordReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *jsonlRecordReader) Next() (int, importRecord, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var record importRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return r.line, record, &rowError{err: fmt.Errorf("invalid JSON: %w", err)}
+		}
+		return r.line, record, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return r.line, importRecord{}, err
+	}
+	return r.line, importRecord{}, io.EOF
+}
+
+type csvRecordReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVRecordReader(r io.Reader) (*csvRecordReader, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: CSV file is empty", ErrInvalidTransfer)
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, fmt.Errorf("%w: unreadable CSV header: %v", ErrInvalidTransfer, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns["originalUrl"]; !ok {
+		return nil, fmt.Errorf("%w: CSV header must include an originalUrl column", ErrInvalidTransfer)
+	}
+	return &csvRecordReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvRecordReader) Next() (int, importRecord, error) {
+	fields, err := r.reader.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr.StartLine, importRecord{}, &rowError{err: parseErr.Err}
+	}
+	if err != nil {
+		return 0, importRecord{}, err
+	}
+	line, _ := r.reader.FieldPos(0)
+
+	field := func(name string) string {
+		if i, ok := r.columns[name]; ok && i < len(fields) {
+			return fields[i]
+		}
+		return ""
+	}
+	record := importRecord{
+		ShortLink:      field("shortLink"),
+		OriginalURL:    field("originalUrl"),
+		ExpirationDate: field("expirationDate"),
+		CreatedAt:      field("createdAt"),
+		ActivatesAt:    field("activatesAt"),
+		FallbackURL:    field("fallbackUrl"),
+	}
+	if ownerID := field("ownerId"); ownerID != "" {
+		id, err := strconv.ParseUint(ownerID, 10, 64)
+		if err != nil {
+			return line, record, &rowError{err: fmt.Errorf("invalid ownerId %q", ownerID)}
+		}
+		owner := uint(id)
+		record.OwnerID = &owner
+	}
+	return line, record, nil
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"url-shortener/models"
+	"url-shortener/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportRoundTrip(t *testing.T) {
+	admin := &models.APIKey{ID: 1, IsAdmin: true}
+	owner := &models.APIKey{ID: 7}
+
+	for _, format := range []TransferFormat{FormatCSV, FormatJSONL} {
+		t.Run(string(format), func(t *testing.T) {
+			source := repositories.NewMemoryURLRepository()
+			urls := NewURLService(source, nil, nil, ExpirationPolicy{})
+			_, err := urls.CreateURL(CreateURLParams{OriginalURL: "https://example.com/a", CustomSlug: "first", Owner: owner, FallbackURL: "https://example.com/ended"})
+			require.NoError(t, err)
+			_, err = urls.CreateURL(CreateURLParams{OriginalURL: "https://other.org/b", CustomSlug: "second", ActivatesAt: time.Now().Add(time.Hour)})
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			require.NoError(t, NewLinkTransferService(source, nil, nil, ExpirationPolicy{}).Export(&buf, format, LinkFilter{}, admin))
+
+			target := repositories.NewMemoryURLRepository()
+			report, err := NewLinkTransferService(target, nil, nil, ExpirationPolicy{}).Import(&buf, ImportOptions{Format: format}, admin)
+			require.NoError(t, err)
+			assert.Equal(t, 2, report.Created)
+			assert.Empty(t, report.Rows)
+
+			for _, slug := range []string{"first", "second"} {
+				original, err := source.FindByShortLink(slug)
+				require.NoError(t, err)
+				imported, err := target.FindByShortLink(slug)
+				require.NoError(t, err)
+				assert.Equal(t, original.OriginalURL, imported.OriginalURL)
+				assert.Equal(t, original.DestinationHost, imported.DestinationHost)
+				assert.Equal(t, original.OwnerID, imported.OwnerID)
+				assert.Equal(t, original.FallbackURL, imported.FallbackURL)
+				assert.True(t, original.ExpirationDate.Equal(imported.ExpirationDate))
+				assert.True(t, original.CreatedAt.Equal(imported.CreatedAt))
+				if original.ActivatesAt != nil && assert.NotNil(t, imported.ActivatesAt) {
+					assert.True(t, original.ActivatesAt.Equal(*imported.ActivatesAt))
+				} else {
+					assert.Nil(t, imported.ActivatesAt)
+				}
+			}
+		})
+	}
+}
+
+func TestExportScope(t *testing.T) {
+	repo := repositories.NewMemoryURLRepository()
+	urls := NewURLService(repo, nil, nil, ExpirationPolicy{})
+	alice := &models.APIKey{ID: 2}
+	_, err := urls.CreateURL(CreateURLParams{OriginalURL: "https://example.com/a", CustomSlug: "mine", Owner: alice})
+	require.NoError(t, err)
+	_, err = urls.CreateURL(CreateURLParams{OriginalURL: "https://example.com/b", CustomSlug: "theirs"})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, NewLinkTransferService(repo, nil, nil, ExpirationPolicy{}).Export(&buf, FormatJSONL, LinkFilter{}, alice))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+	var record LinkRecord
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "mine", record.ShortLink)
+
+	buf.Reset()
+	require.NoError(t, NewLinkTransferService(repo, nil, nil, ExpirationPolicy{}).Export(&buf, FormatCSV, LinkFilter{Domain: "nowhere.test"}, alice))
+	assert.Equal(t, strings.Join(csvColumns, ",")+"\n", buf.String())
+
+	buf.Reset()
+	assert.ErrorIs(t, NewLinkTransferService(repo, nil, nil, ExpirationPolicy{}).Export(&buf, "xml", LinkFilter{}, alice), ErrInvalidTransfer)
+	assert.ErrorIs(t, NewLinkTransferService(repo, nil, nil, ExpirationPolicy{}).Export(&buf, FormatCSV, LinkFilter{Status: "gone"}, alice), ErrInvalidListQuery)
+	assert.Zero(t, buf.Len())
+}
+
+func TestImportConflictsAndErrors(t *testing.T) {
+	alice := &models.APIKey{ID: 2}
+	csvFile := strings.Join([]string{
+		"shortLink,originalUrl,expirationDate,ownerId",
+		"taken,https://example.com/new,2030-01-01,",
+		"fresh,https://example.com/fresh,,99",
+		"bad,ftp://example.com,,",
+		"when,https://example.com/when,someday,",
+		"short,https://example.com/x",
+		",https://example.com/random,,",
+		"fresh,https://example.com/again,,",
+		"brave-otter-42,https://example.com/words,,",
+		"-dash,https://example.com/dash,,",
+	}, "\n")
+
+	tests := []struct {
+		policy  ConflictPolicy
+		actions map[int]ImportAction // by line; lines not listed were created
+	}{
+		{ConflictSkip, map[int]ImportAction{2: ImportSkipped, 8: ImportSkipped}},
+		{ConflictOverwrite, map[int]ImportAction{2: ImportOverwritten, 8: ImportOverwritten}},
+		{ConflictRename, map[int]ImportAction{2: ImportRenamed, 8: ImportRenamed}},
+	}
+
+	for _, tt := range tests {
+		for _, dryRun := range []bool{true, false} {
+			repo := repositories.NewMemoryURLRepository()
+			_, err := NewURLService(repo, nil, nil, ExpirationPolicy{}).CreateURL(CreateURLParams{OriginalURL: "https://example.com/old", CustomSlug: "taken", Owner: alice})
+			require.NoError(t, err)
+
+			report, err := NewLinkTransferService(repo, nil, nil, ExpirationPolicy{}).Import(strings.NewReader(csvFile), ImportOptions{Format: FormatCSV, Conflict: tt.policy, DryRun: dryRun}, alice)
+			require.NoError(t, err, tt.policy)
+
+			// Invalid URL, unparseable date, a short row and a malformed slug fail whatever
+			// the policy; generated slugs are accepted even though they aren't valid custom slugs
+			actions := map[int]ImportAction{4: ImportFailed, 5: ImportFailed, 6: ImportFailed, 10: ImportFailed}
+			for line, action := range tt.actions {
+				actions[line] = action
+			}
+			got := map[int]ImportAction{}
+			for _, row := range report.Rows {
+				got[row.Line] = row.Action
+			}
+			assert.Equal(t, actions, got, "%s dryRun=%t", tt.policy, dryRun)
+			assert.Equal(t, 3, report.Created, tt.policy)
+			assert.Equal(t, dryRun, report.DryRun)
+
+			fresh, err := repo.FindByShortLink("fresh")
+			if dryRun {
+				assert.ErrorIs(t, err, repositories.ErrNotFound)
+				taken, err := repo.FindByShortLink("taken")
+				require.NoError(t, err)
+				assert.Equal(t, "https://example.com/old", taken.OriginalURL)
+				continue
+			}
+			require.NoError(t, err)
+			// Non-admins can't assign links to someone else
+			assert.Equal(t, &alice.ID, fresh.OwnerID)
+
+			taken, err := repo.FindByShortLink("taken")
+			require.NoError(t, err)
+			switch tt.policy {
+			case ConflictOverwrite:
+				assert.Equal(t, "https://example.com/new", taken.OriginalURL)
+				assert.Equal(t, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), taken.ExpirationDate)
+				assert.Equal(t, "https://example.com/again", fresh.OriginalURL)
+			case ConflictRename:
+				assert.Equal(t, "https://example.com/old", taken.OriginalURL)
+				for _, row := range report.Rows {
+					if row.Action == ImportRenamed {
+						renamed, err := repo.FindByShortLink(row.ShortLink)
+						require.NoError(t, err)
+						assert.NotEqual(t, row.RenamedFrom, row.ShortLink)
+						assert.Contains(t, renamed.OriginalURL, "https://example.com/")
+					}
+				}
+			default:
+				assert.Equal(t, "https://example.com/old", taken.OriginalURL)
+			}
+		}
+	}
+}
+
+func TestImportOverDeletedSlug(t *testing.T) {
+	owner := &models.APIKey{ID: 2}
+	tests := map[ConflictPolicy]ImportAction{
+		ConflictSkip:      ImportSkipped,
+		ConflictOverwrite: ImportFailed,
+		ConflictRename:    ImportRenamed,
+	}
+	for policy, action := range tests {
+		repo := repositories.NewMemoryURLRepository()
+		urls := NewURLService(repo, nil, nil, ExpirationPolicy{})
+		_, err := urls.CreateURL(CreateURLParams{OriginalURL: "https://example.com/old", CustomSlug: "gone", Owner: owner})
+		require.NoError(t, err)
+		require.NoError(t, urls.DeleteURL("gone", owner))
+
+		report, err := NewLinkTransferService(repo, nil, nil, ExpirationPolicy{}).Import(strings.NewReader("shortLink,originalUrl\ngone,https://example.com/new\n"), ImportOptions{Format: FormatCSV, Conflict: policy}, owner)
+		require.NoError(t, err, policy)
+		require.Len(t, report.Rows, 1, policy)
+		assert.Equal(t, action, report.Rows[0].Action, policy)
+		if action == ImportFailed {
+			assert.Equal(t, ErrSlugDeleted.Error(), report.Rows[0].Error)
+		}
+
+		// The tombstone is left as it was
+		tombstone, err := repo.FindDeletedByShortLink("gone")
+		require.NoError(t, err, policy)
+		assert.Equal(t, "https://example.com/old", tombstone.OriginalURL)
+	}
+}
+
+// brokenURLRepository fails every write, as a database that went away would.
+type brokenURLRepository struct {
+	repositories.URLRepository
+}
+
+func (brokenURLRepository) Create(url *models.URL) error { return errors.New("connection refused") }
+func (brokenURLRepository) Update(url *models.URL) error { return errors.New("connection refused") }
+
+func TestImportStorageFailure(t *testing.T) {
+	repo := repositories.NewMemoryURLRepository()
+	owner := &models.APIKey{ID: 2}
+	_, err := NewURLService(repo, nil, nil, ExpirationPolicy{}).CreateURL(CreateURLParams{OriginalURL: "https://example.com/old", CustomSlug: "taken", Owner: owner})
+	require.NoError(t, err)
+	transfer := NewLinkTransferService(brokenURLRepository{repo}, nil, nil, ExpirationPolicy{})
+
+	// Storage failures abort the import rather than failing each row with the driver's message
+	for _, csvFile := range []string{"shortLink,originalUrl\nfresh,https://example.com/new\n", "shortLink,originalUrl\ntaken,https://example.com/new\n"} {
+		report, err := transfer.Import(strings.NewReader(csvFile), ImportOptions{Format: FormatCSV, Conflict: ConflictOverwrite}, owner)
+		assert.Error(t, err)
+		assert.Nil(t, report)
+	}
+}
+
+func TestImportForbiddenOverwrite(t *testing.T) {
+	repo := repositories.NewMemoryURLRepository()
+	_, err := NewURLService(repo, nil, nil, ExpirationPolicy{}).CreateURL(CreateURLParams{OriginalURL: "https://example.com/old", CustomSlug: "bobs", Owner: &models.APIKey{ID: 3}})
+	require.NoError(t, err)
+
+	jsonl := `{"shortLink":"bobs","originalUrl":"https://evil.example"}` + "\n" + `not json` + "\n"
+	report, err := NewLinkTransferService(repo, nil, nil, ExpirationPolicy{}).Import(strings.NewReader(jsonl), ImportOptions{Format: FormatJSONL, Conflict: ConflictOverwrite}, &models.APIKey{ID: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Failed)
+	if assert.Len(t, report.Rows, 2) {
+		assert.Equal(t, ErrForbidden.Error(), report.Rows[0].Error)
+		assert.Equal(t, 2, report.Rows[1].Line)
+	}
+
+	_, err = NewLinkTransferService(repo, nil, nil, ExpirationPolicy{}).Import(strings.NewReader("shortLink\nabc\n"), ImportOptions{Format: FormatCSV}, &models.APIKey{ID: 2})
+	assert.ErrorIs(t, err, ErrInvalidTransfer)
+	_, err = NewLinkTransferService(repo, nil, nil, ExpirationPolicy{}).Import(strings.NewReader(""), ImportOptions{Format: FormatJSONL, Conflict: "merge"}, &models.APIKey{ID: 2})
+	assert.ErrorIs(t, err, ErrInvalidTransfer)
+}
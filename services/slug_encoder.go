@@ -0,0 +1,145 @@
+// services/slug_encoder.go
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// feistelRounds is enough rounds for consecutive values to land far apart; the permutation
+// only hides ordering, it isn't meant to withstand cryptanalysis.
+const feistelRounds = 4
+
+// SlugEncoder maps sequence values to slugs one to one. Values fill every slug of the
+// minimum length first, then every slug one character longer, and so on. Within a length a
+// keyed permutation scatters consecutive values, and the alphabet itself is shuffled by the
+// key, so slugs reveal neither their order nor how many links exist.
+type SlugEncoder struct {
+	alphabet  string // Shuffled
+	minLength int
+	key       []byte
+}
+
+// NewSlugEncoder validates alphabet like the random generator does. Instances sharing a
+// sequence must use the same alphabet, minLength and secret, or their slugs may clash.
+func NewSlugEncoder(alphabet string, minLength int, secret string) (*SlugEncoder, error) {
+	if err := validateSlugAlphabet(alphabet); err != nil {
+		return nil, err
+	}
+	if minLength <= 0 || minLength > MaxGeneratedSlugLength {
+		return nil, fmt.Errorf("slug length must be between 1 and %d", MaxGeneratedSlugLength)
+	}
+	encoder := &SlugEncoder{minLength: minLength, key: []byte(secret)}
+	encoder.alphabet = encoder.shuffle(alphabet)
+	return encoder, nil
+}
+
+// Encode returns the slug for value, or ErrSlugSpaceExhausted once value needs slugs longer
+// than MaxGeneratedSlugLength.
+func (e *SlugEncoder) Encode(value int64) (string, error) {
+	if value < 0 {
+		return "", errors.New("sequence values can't be negative")
+	}
+	length, offset := e.minLength, uint64(value)
+	for {
+		size := e.keyspace(length)
+		if offset < size {
+			break
+		}
+		offset -= size
+		length++
+		if length > MaxGeneratedSlugLength {
+			return "", ErrSlugSpaceExhausted
+		}
+	}
+
+	n := e.permute(length, offset, false)
+	base := uint64(len(e.alphabet))
+	slug := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		slug[i] = e.alphabet[n%base]
+		n /= base
+	}
+	return string(slug), nil
+}
+
+// Decode reverses Encode, reporting false for strings Encode never returns.
+func (e *SlugEncoder) Decode(slug string) (int64, bool) {
+	if len(slug) < e.minLength || len(slug) > MaxGeneratedSlugLength {
+		return 0, false
+	}
+	base := uint64(len(e.alphabet))
+	var n uint64
+	for i := 0; i < len(slug); i++ {
+		digit := strings.IndexByte(e.alphabet, slug[i])
+		if digit < 0 {
+			return 0, false
+		}
+		n = n*base + uint64(digit)
+	}
+
+	value := e.permute(len(slug), n, true)
+	for length := e.minLength; length < len(slug); length++ {
+		value += e.keyspace(length)
+	}
+	return int64(value), true
+}
+
+// keyspace is the number of slugs of the given length.
+func (e *SlugEncoder) keyspace(length int) uint64 {
+	size := uint64(1)
+	for i := 0; i < length; i++ {
+		size *= uint64(len(e.alphabet))
+	}
+	return size
+}
+
+// permute applies (or inverts) a keyed permutation of [0, keyspace(length)). A Feistel
+// network permutes the smallest even power of two that covers the keyspace, and results
+// outside the keyspace are fed back in until one lands inside ("cycle walking").
+func (e *SlugEncoder) permute(length int, n uint64, inverse bool) uint64 {
+	size := e.keyspace(length)
+	half := (bits.Len64(size-1) + 1) / 2
+	mask := uint64(1)<<half - 1
+
+	for {
+		left, right := n>>half, n&mask
+		for i := 0; i < feistelRounds; i++ {
+			if inverse {
+				round := feistelRounds - 1 - i
+				left, right = right^(e.round(length, round, left)&mask), left
+			} else {
+				left, right = right, left^(e.round(length, i, right)&mask)
+			}
+		}
+		n = left<<half | right
+		if n < size {
+			return n
+		}
+	}
+}
+
+// round is the Feistel round function, keyed by the secret and the slug length.
+func (e *SlugEncoder) round(length, round int, half uint64) uint64 {
+	var input [10]byte
+	input[0], input[1] = byte(length), byte(round)
+	binary.BigEndian.PutUint64(input[2:], half)
+	mac := hmac.New(sha256.New, e.key)
+	mac.Write(input[:])
+	return binary.BigEndian.Uint64(mac.Sum(nil))
+}
+
+// shuffle permutes alphabet with a Fisher-Yates shuffle driven by the key.
+func (e *SlugEncoder) shuffle(alphabet string) string {
+	shuffled := []byte(alphabet)
+	for i := len(shuffled) - 1; i > 0; i-- {
+		j := e.round(0, i, 0) % uint64(i+1)
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	}
+	return string(shuffled)
+}
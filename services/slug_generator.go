@@ -0,0 +1,184 @@
+// services/slug_generator.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"url-shortener/logging"
+	"url-shortener/utils"
+)
+
+// DefaultSlugAlphabet is base62 without the look-alikes 0/O/o and 1/l/I, so slugs survive
+// being read aloud or retyped.
+const DefaultSlugAlphabet = "23456789abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// MaxGeneratedSlugLength bounds random and sequential slugs, which are meant to stay short.
+const MaxGeneratedSlugLength = 10
+
+// maxSlugAttempts bounds how many taken candidates NewSlug tolerates for a single link.
+const maxSlugAttempts = 100
+
+// ErrSlugSpaceExhausted is returned when no free slug turned up within maxSlugAttempts.
+var ErrSlugSpaceExhausted = errors.New("could not find a free slug")
+
+// SlugGenerator picks the slugs of links created without a custom slug.
+type SlugGenerator interface {
+	// NewSlug returns a slug for which taken reports false. Generators whose slugs can't
+	// collide with each other may skip the check.
+	NewSlug(taken func(slug string) (bool, error)) (string, error)
+}
+
+// RandomSlugConfig configures NewRandomSlugGenerator. Zero values fall back to defaults.
+type RandomSlugConfig struct {
+	Length    int    // Initial slug length, default 6
+	MaxLength int    // Length never grows beyond this, default MaxGeneratedSlugLength
+	Alphabet  string // Alphanumeric characters to draw from, default DefaultSlugAlphabet
+
+	// Length grows by one when more than GrowthThreshold of the last GrowthWindow
+	// candidates were already taken. Defaults: 0.1 and 100.
+	GrowthThreshold float64
+	GrowthWindow    int
+}
+
+// RandomSlugStats describes a RandomSlugGenerator's current state.
+type RandomSlugStats struct {
+	Length     int   `json:"length"`
+	Attempts   int64 `json:"attempts"`
+	Collisions int64 `json:"collisions"`
+}
+
+// RandomSlugGenerator draws slugs from crypto/rand. It watches how often candidates are
+// already taken and lengthens slugs as the keyspace fills up.
+type RandomSlugGenerator struct {
+	config RandomSlugConfig
+
+	mu         sync.Mutex
+	length     int
+	recent     []bool // Ring buffer of the last GrowthWindow outcomes, true for a collision
+	next       int
+	filled     int
+	collisions int // Collisions currently in recent
+	attempts   int64
+	total      int64 // Collisions since start
+}
+
+func NewRandomSlugGenerator(config RandomSlugConfig) (*RandomSlugGenerator, error) {
+	if config.Length <= 0 {
+		config.Length = 6
+	}
+	if config.MaxLength <= 0 {
+		config.MaxLength = MaxGeneratedSlugLength
+	}
+	if config.Alphabet == "" {
+		config.Alphabet = DefaultSlugAlphabet
+	}
+	if config.GrowthThreshold <= 0 {
+		config.GrowthThreshold = 0.1
+	}
+	if config.GrowthWindow <= 0 {
+		config.GrowthWindow = 100
+	}
+
+	if config.MaxLength > MaxGeneratedSlugLength || config.Length > config.MaxLength {
+		return nil, fmt.Errorf("slug length must be at most the max length, which is at most %d", MaxGeneratedSlugLength)
+	}
+	if err := validateSlugAlphabet(config.Alphabet); err != nil {
+		return nil, err
+	}
+
+	return &RandomSlugGenerator{
+		config: config,
+		length: config.Length,
+		recent: make([]bool, config.GrowthWindow),
+	}, nil
+}
+
+func (g *RandomSlugGenerator) NewSlug(taken func(slug string) (bool, error)) (string, error) {
+	for attempt := 0; attempt < maxSlugAttempts; attempt++ {
+		slug, err := utils.RandomString(g.config.Alphabet, g.currentLength())
+		if err != nil {
+			return "", err
+		}
+		collided, err := taken(slug)
+		if err != nil {
+			return "", err
+		}
+		g.observe(collided)
+		if !collided {
+			return slug, nil
+		}
+	}
+	return "", ErrSlugSpaceExhausted
+}
+
+func (g *RandomSlugGenerator) Stats() RandomSlugStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return RandomSlugStats{Length: g.length, Attempts: g.attempts, Collisions: g.total}
+}
+
+func (g *RandomSlugGenerator) currentLength() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.length
+}
+
+// observe records one candidate and grows the length once the window's collision rate
+// passes the threshold. The window restarts after growing, as old outcomes no longer apply.
+func (g *RandomSlugGenerator) observe(collided bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.attempts++
+	if collided {
+		g.total++
+		g.collisions++
+	}
+	if g.filled == len(g.recent) && g.recent[g.next] {
+		g.collisions--
+	}
+	g.recent[g.next] = collided
+	g.next = (g.next + 1) % len(g.recent)
+	if g.filled < len(g.recent) {
+		g.filled++
+	}
+
+	rate := float64(g.collisions) / float64(len(g.recent))
+	if g.filled == len(g.recent) && rate > g.config.GrowthThreshold && g.length < g.config.MaxLength {
+		g.length++
+		logging.Log.WithField("length", g.length).WithField("collision_rate", rate).Warn("Slug collision rate is high; generating longer slugs")
+		clear(g.recent)
+		g.next, g.filled, g.collisions = 0, 0, 0
+	}
+}
+
+func orDefaultSlugGenerator(slugs SlugGenerator) SlugGenerator {
+	if slugs != nil {
+		return slugs
+	}
+	generator, err := NewRandomSlugGenerator(RandomSlugConfig{})
+	if err != nil {
+		panic(err) // The defaults are valid
+	}
+	return generator
+}
+
+// validateSlugAlphabet requires distinct ASCII letters and digits, so generated slugs are
+// URL-safe without escaping.
+func validateSlugAlphabet(alphabet string) error {
+	if len(alphabet) < 2 {
+		return errors.New("slug alphabet needs at least two characters")
+	}
+	seen := make(map[rune]bool, len(alphabet))
+	for _, r := range alphabet {
+		if !isAlphanumeric(string(r)) {
+			return fmt.Errorf("slug alphabet may only contain ASCII letters and digits, got %q", r)
+		}
+		if seen[r] {
+			return fmt.Errorf("slug alphabet repeats %q", r)
+		}
+		seen[r] = true
+	}
+	return nil
+}
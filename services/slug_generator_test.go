@@ -0,0 +1,68 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func never(string) (bool, error) { return false, nil }
+
+func TestRandomSlugGeneratorDefaults(t *testing.T) {
+	generator, err := NewRandomSlugGenerator(RandomSlugConfig{})
+	require.NoError(t, err)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		slug, err := generator.NewSlug(never)
+		require.NoError(t, err)
+		assert.Len(t, slug, 6)
+		assert.False(t, strings.ContainsAny(slug, "0Oo1lI"), slug)
+		seen[slug] = true
+	}
+	assert.Len(t, seen, 1000)
+	assert.Equal(t, RandomSlugStats{Length: 6, Attempts: 1000}, generator.Stats())
+}
+
+func TestRandomSlugGeneratorConfig(t *testing.T) {
+	generator, err := NewRandomSlugGenerator(RandomSlugConfig{Length: 4, Alphabet: "ab"})
+	require.NoError(t, err)
+	slug, err := generator.NewSlug(never)
+	require.NoError(t, err)
+	assert.Len(t, slug, 4)
+	assert.Empty(t, strings.Trim(slug, "ab"))
+
+	for _, config := range []RandomSlugConfig{
+		{Alphabet: "a"},
+		{Alphabet: "abca"},
+		{Alphabet: "ab-"},
+		{Length: 12},
+		{Length: 8, MaxLength: 7},
+	} {
+		_, err := NewRandomSlugGenerator(config)
+		assert.Error(t, err, "%+v", config)
+	}
+}
+
+func TestRandomSlugGeneratorGrowsOnCollisions(t *testing.T) {
+	generator, err := NewRandomSlugGenerator(RandomSlugConfig{Length: 3, MaxLength: 5, GrowthThreshold: 0.5, GrowthWindow: 10})
+	require.NoError(t, err)
+
+	// Every short slug collides, so the generator has to lengthen them to get through
+	takenBelow := func(length int) func(string) (bool, error) {
+		return func(slug string) (bool, error) { return len(slug) < length, nil }
+	}
+	slug, err := generator.NewSlug(takenBelow(5))
+	require.NoError(t, err)
+	assert.Len(t, slug, 5)
+	stats := generator.Stats()
+	assert.Equal(t, 5, stats.Length)
+	assert.EqualValues(t, 20, stats.Collisions) // A full window of 10 per step
+
+	// At the maximum length it gives up rather than loop forever
+	_, err = generator.NewSlug(takenBelow(6))
+	assert.ErrorIs(t, err, ErrSlugSpaceExhausted)
+	assert.Equal(t, 5, generator.Stats().Length)
+}
@@ -0,0 +1,124 @@
+// services/slug_policy.go
+package services
+
+import (
+	"fmt"
+	"strings"
+	"url-shortener/utils"
+)
+
+// Bounds for custom slugs, matching the binding rules on request.CreateURLRequest.
+const (
+	MinSlugLength = 3
+	MaxSlugLength = 8
+
+	maxSlugSuggestions = 5
+
+	// MaxShortLinkLength is the width of urls.short_link, which also holds generated slugs
+	MaxShortLinkLength = 32
+)
+
+// SlugReason explains why a slug can't be used.
+type SlugReason string
+
+const (
+	SlugTaken          SlugReason = "taken"
+	SlugReserved       SlugReason = "reserved"
+	SlugInvalidCharset SlugReason = "invalid_charset"
+	SlugTooShort       SlugReason = "too_short"
+	SlugTooLong        SlugReason = "too_long"
+)
+
+// SlugAvailability is the result of checking a candidate custom slug.
+type SlugAvailability struct {
+	Slug        string
+	Available   bool
+	Reason      SlugReason // Empty when available
+	Suggestions []string   // Close alternatives that are available, when the slug isn't
+}
+
+// slugFormatReason checks length and charset; it returns "" for a usable slug. Reserved
+// words are the SlugRegistry's concern.
+func slugFormatReason(slug string) SlugReason {
+	switch {
+	case len(slug) < MinSlugLength:
+		return SlugTooShort
+	case len(slug) > MaxSlugLength:
+		return SlugTooLong
+	case !isAlphanumeric(slug):
+		return SlugInvalidCharset
+	}
+	return ""
+}
+
+// storedSlugReason accepts anything a generator may have produced: longer slugs and the
+// hyphens of word slugs. Imports use it so exported links come back unchanged.
+func storedSlugReason(slug string) SlugReason {
+	switch {
+	case len(slug) < MinSlugLength:
+		return SlugTooShort
+	case len(slug) > MaxShortLinkLength:
+		return SlugTooLong
+	case strings.HasPrefix(slug, "-") || strings.HasSuffix(slug, "-") || !isAlphanumeric(strings.ReplaceAll(slug, "-", "")):
+		return SlugInvalidCharset
+	}
+	return ""
+}
+
+func isAlphanumeric(value string) bool {
+	for _, r := range value {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// sanitizeSlug strips characters outside the slug charset and fits the result to the length bounds.
+func sanitizeSlug(slug string) string {
+	var b strings.Builder
+	for _, r := range slug {
+		if isAlphanumeric(string(r)) {
+			b.WriteRune(r)
+		}
+	}
+	cleaned := b.String()
+	if len(cleaned) > MaxSlugLength {
+		cleaned = cleaned[:MaxSlugLength]
+	}
+	return cleaned
+}
+
+// slugCandidates proposes alternatives to slug in order of closeness: numeric suffixes first,
+// then a couple of random suffixes. Candidates may still be taken or reserved; the caller
+// filters them.
+func slugCandidates(slug string) []string {
+	base := sanitizeSlug(slug)
+	var candidates []string
+	add := func(candidate string) {
+		if slugFormatReason(candidate) == "" {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	// Short bases are padded, long ones trimmed to leave room for a suffix
+	for suffix := 1; suffix <= 9; suffix++ {
+		tail := fmt.Sprint(suffix)
+		if len(base) < MinSlugLength {
+			tail = strings.Repeat("0", MinSlugLength-len(base)-1) + tail
+		}
+		head := base
+		if len(head)+len(tail) > MaxSlugLength {
+			head = head[:MaxSlugLength-len(tail)]
+		}
+		add(head + tail)
+	}
+	head := base
+	if len(head) > MaxSlugLength-2 {
+		head = head[:MaxSlugLength-2]
+	}
+	for i := 0; i < 3; i++ {
+		add(head + utils.GenerateRandomSlug(max(2, MinSlugLength-len(head))))
+	}
+	return candidates
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+	"url-shortener/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlugFormatReason(t *testing.T) {
+	tests := map[string]SlugReason{
+		"promo":      "",
+		"ab":         SlugTooShort,
+		"waytoolong": SlugTooLong,
+		"no-dash":    SlugInvalidCharset,
+		"ümlaut":     SlugInvalidCharset,
+		"PING":       "", // Reserved words are the registry's to reject
+	}
+	for slug, want := range tests {
+		assert.Equal(t, want, slugFormatReason(slug), slug)
+	}
+}
+
+func TestCheckSlugAvailability(t *testing.T) {
+	service := NewURLService(repositories.NewMemoryURLRepository(), nil, nil, ExpirationPolicy{})
+	_, err := service.CreateURL(CreateURLParams{OriginalURL: "https://example.com", CustomSlug: "promo"})
+	require.NoError(t, err)
+	_, err = service.CreateURL(CreateURLParams{OriginalURL: "https://example.com", CustomSlug: "promo1"})
+	require.NoError(t, err)
+
+	result, err := service.CheckSlugAvailability("fresh")
+	require.NoError(t, err)
+	assert.True(t, result.Available)
+	assert.Empty(t, result.Suggestions)
+
+	result, err = service.CheckSlugAvailability("promo")
+	require.NoError(t, err)
+	assert.False(t, result.Available)
+	assert.Equal(t, SlugTaken, result.Reason)
+	assert.Len(t, result.Suggestions, maxSlugSuggestions)
+	assert.NotContains(t, result.Suggestions, "promo1", "taken alternatives are filtered out")
+	assert.Equal(t, "promo2", result.Suggestions[0])
+
+	for _, slug := range []string{"x", "springsale2025", "my-link", "admin"} {
+		result, err = service.CheckSlugAvailability(slug)
+		require.NoError(t, err)
+		assert.False(t, result.Available, slug)
+		assert.NotEmpty(t, result.Suggestions, slug)
+		for _, suggestion := range result.Suggestions {
+			assert.Empty(t, slugFormatReason(suggestion), "suggestion %q for %q must be valid", suggestion, slug)
+		}
+	}
+
+	_, err = service.CreateURL(CreateURLParams{OriginalURL: "https://example.com", CustomSlug: "admin"})
+	assert.ErrorIs(t, err, ErrSlugReserved)
+}
@@ -0,0 +1,351 @@
+// services/slug_registry.go
+package services
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"url-shortener/models"
+	"url-shortener/repositories"
+)
+
+// Where a reserved slug comes from.
+const (
+	ReservedBuiltIn = "builtin" // Route names, which /:shortLink would shadow
+	ReservedFile    = "file"    // The blocklist file
+	ReservedAdmin   = "admin"   // Added through the admin API
+)
+
+// reservedPatternPrefix marks a regular expression in the blocklist file.
+const reservedPatternPrefix = "re:"
+
+var (
+	ErrInvalidReservedSlug  = errors.New("invalid reserved slug")
+	ErrReservedSlugExists   = errors.New("slug is reserved already")
+	ErrReservedSlugNotFound = errors.New("reserved slug not found")
+)
+
+// builtInReservedSlugs seed every registry: they are, or may become, top-level routes that
+// /:shortLink would otherwise shadow.
+var builtInReservedSlugs = map[string]struct{}{
+	"admin":    {},
+	"api":      {},
+	"debug":    {},
+	"generate": {},
+	"health":   {},
+	"metrics":  {},
+	"ping":     {},
+	"static":   {},
+}
+
+// ReservedSlugEntry is a word or pattern no slug may use. ID is only set for admin entries.
+type ReservedSlugEntry struct {
+	ID        uint
+	Value     string
+	Pattern   bool
+	Note      string
+	Source    string
+	CreatedAt time.Time
+}
+
+// ReservedSlugService manages the reserved slugs added through the admin API.
+type ReservedSlugService interface {
+	List() ([]ReservedSlugEntry, error)
+	Add(value string, pattern bool, note string) (*ReservedSlugEntry, error)
+	Remove(id uint) error
+}
+
+// SlugRegistryConfig configures NewSlugRegistry. Zero values fall back to defaults.
+type SlugRegistryConfig struct {
+	// BlocklistFile lists blocked words, one per line, and regular expressions prefixed
+	// with "re:". Blank lines and # comments are ignored. Optional.
+	BlocklistFile string
+	// RefreshInterval is how long admin entries are cached, so that entries added on
+	// another instance take effect. Default 30s.
+	RefreshInterval time.Duration
+}
+
+// reservedMatcher matches a whole slug against a word, or any part of it against a pattern.
+// Both ignore case.
+type reservedMatcher struct {
+	word    string
+	pattern *regexp.Regexp
+}
+
+func (m reservedMatcher) matches(slug string) bool {
+	if m.pattern != nil {
+		return m.pattern.MatchString(slug)
+	}
+	return m.word == slug
+}
+
+// SlugRegistry decides which slugs are reserved: built-in route names, the blocklist file
+// and entries added by admins. Custom slugs that match are rejected and generated ones are
+// drawn again. Links created before a slug was reserved keep working.
+type SlugRegistry struct {
+	repo    repositories.ReservedSlugRepository // nil when entries can't be managed
+	refresh time.Duration
+
+	mu       sync.RWMutex
+	builtIn  map[string]struct{}
+	file     []reservedMatcher
+	fileRaw  []string
+	admin    []reservedMatcher
+	loadedAt time.Time // Zero until admin entries were first loaded
+}
+
+func NewSlugRegistry(repo repositories.ReservedSlugRepository, config SlugRegistryConfig) (*SlugRegistry, error) {
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = 30 * time.Second
+	}
+	registry := &SlugRegistry{repo: repo, refresh: config.RefreshInterval, builtIn: make(map[string]struct{})}
+	for slug := range builtInReservedSlugs {
+		registry.builtIn[slug] = struct{}{}
+	}
+	if config.BlocklistFile != "" {
+		if err := registry.loadFile(config.BlocklistFile); err != nil {
+			return nil, err
+		}
+	}
+	return registry, nil
+}
+
+// ReserveRoutes reserves the first segment of each route path, so links never shadow
+// routes. Parameter and wildcard segments are skipped.
+func (r *SlugRegistry) ReserveRoutes(paths []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, path := range paths {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+		if segment != "" && !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			r.builtIn[strings.ToLower(segment)] = struct{}{}
+		}
+	}
+}
+
+// IsReserved reports whether slug may not be used. It only fails when admin entries are
+// due for a reload and the store can't be read.
+func (r *SlugRegistry) IsReserved(slug string) (bool, error) {
+	if err := r.refreshAdmin(false); err != nil {
+		return false, err
+	}
+
+	slug = strings.ToLower(slug)
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, reserved := r.builtIn[slug]; reserved {
+		return true, nil
+	}
+	for _, matchers := range [][]reservedMatcher{r.file, r.admin} {
+		for _, matcher := range matchers {
+			if matcher.matches(slug) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// List returns every reserved slug: built-in ones first, then the file's, then admin entries.
+func (r *SlugRegistry) List() ([]ReservedSlugEntry, error) {
+	var admin []models.ReservedSlug
+	if r.repo != nil {
+		var err error
+		if admin, err = r.repo.FindAll(); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.RLock()
+	builtIn := make([]string, 0, len(r.builtIn))
+	for slug := range r.builtIn {
+		builtIn = append(builtIn, slug)
+	}
+	fileRaw := r.fileRaw
+	r.mu.RUnlock()
+	sort.Strings(builtIn)
+
+	entries := make([]ReservedSlugEntry, 0, len(builtIn)+len(fileRaw)+len(admin))
+	for _, slug := range builtIn {
+		entries = append(entries, ReservedSlugEntry{Value: slug, Source: ReservedBuiltIn})
+	}
+	for _, line := range fileRaw {
+		value, pattern := strings.CutPrefix(line, reservedPatternPrefix)
+		entries = append(entries, ReservedSlugEntry{Value: value, Pattern: pattern, Source: ReservedFile})
+	}
+	for _, entry := range admin {
+		entries = append(entries, toReservedSlugEntry(entry))
+	}
+	return entries, nil
+}
+
+// Add reserves a word (matched against whole slugs) or a regular expression (matched
+// against any part of a slug). Both ignore case.
+func (r *SlugRegistry) Add(value string, pattern bool, note string) (*ReservedSlugEntry, error) {
+	if r.repo == nil {
+		return nil, errors.New("reserved slugs can't be managed without a store")
+	}
+	matcher, err := newReservedMatcher(value, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &models.ReservedSlug{Value: strings.TrimSpace(value), IsPattern: pattern, Note: strings.TrimSpace(note)}
+	if !pattern {
+		entry.Value = matcher.word
+	}
+	if err := r.repo.Create(entry); err != nil {
+		if errors.Is(err, repositories.ErrDuplicateReservedSlug) {
+			return nil, ErrReservedSlugExists
+		}
+		return nil, err
+	}
+	if err := r.refreshAdmin(true); err != nil {
+		return nil, err
+	}
+	result := toReservedSlugEntry(*entry)
+	return &result, nil
+}
+
+// Remove deletes an admin entry. Built-in and file entries can't be removed at runtime.
+func (r *SlugRegistry) Remove(id uint) error {
+	if r.repo == nil {
+		return ErrReservedSlugNotFound
+	}
+	if err := r.repo.Delete(id); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return ErrReservedSlugNotFound
+		}
+		return err
+	}
+	return r.refreshAdmin(true)
+}
+
+// refreshAdmin reloads admin entries once they are older than the refresh interval, or
+// right away when forced.
+func (r *SlugRegistry) refreshAdmin(force bool) error {
+	if r.repo == nil {
+		return nil
+	}
+	r.mu.RLock()
+	fresh := !r.loadedAt.IsZero() && time.Since(r.loadedAt) < r.refresh
+	r.mu.RUnlock()
+	if fresh && !force {
+		return nil
+	}
+
+	entries, err := r.repo.FindAll()
+	if err != nil {
+		return err
+	}
+	admin := make([]reservedMatcher, 0, len(entries))
+	for _, entry := range entries {
+		matcher, err := newReservedMatcher(entry.Value, entry.IsPattern)
+		if err != nil {
+			continue // Validated when added; skip rather than block every slug
+		}
+		admin = append(admin, matcher)
+	}
+
+	r.mu.Lock()
+	r.admin, r.loadedAt = admin, time.Now()
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *SlugRegistry) loadFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("reading slug blocklist: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		value, pattern := strings.CutPrefix(text, reservedPatternPrefix)
+		matcher, err := newReservedMatcher(value, pattern)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		r.file = append(r.file, matcher)
+		r.fileRaw = append(r.fileRaw, text)
+	}
+	return scanner.Err()
+}
+
+// newReservedMatcher validates a word or pattern. Words may only contain characters that
+// slugs can contain.
+func newReservedMatcher(value string, pattern bool) (reservedMatcher, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || len(value) > 255 {
+		return reservedMatcher{}, fmt.Errorf("%w: value must have 1-255 characters", ErrInvalidReservedSlug)
+	}
+	if pattern {
+		re, err := regexp.Compile("(?i)" + value)
+		if err != nil {
+			return reservedMatcher{}, fmt.Errorf("%w: %v", ErrInvalidReservedSlug, err)
+		}
+		return reservedMatcher{pattern: re}, nil
+	}
+	if !isAlphanumeric(strings.ReplaceAll(value, "-", "")) {
+		return reservedMatcher{}, fmt.Errorf("%w: words may only contain letters, digits and hyphens", ErrInvalidReservedSlug)
+	}
+	return reservedMatcher{word: strings.ToLower(value)}, nil
+}
+
+func toReservedSlugEntry(entry models.ReservedSlug) ReservedSlugEntry {
+	return ReservedSlugEntry{
+		ID:        entry.ID,
+		Value:     entry.Value,
+		Pattern:   entry.IsPattern,
+		Note:      entry.Note,
+		Source:    ReservedAdmin,
+		CreatedAt: entry.CreatedAt,
+	}
+}
+
+func orDefaultSlugRegistry(reserved *SlugRegistry) *SlugRegistry {
+	if reserved != nil {
+		return reserved
+	}
+	registry, err := NewSlugRegistry(nil, SlugRegistryConfig{})
+	if err != nil {
+		panic(err) // Without a file there is nothing to fail
+	}
+	return registry
+}
+
+// generateSlug asks generator for a slug that is neither taken nor reserved. Generators
+// that skip the taken check are asked again when their slug is reserved.
+func generateSlug(generator SlugGenerator, taken func(string) (bool, error), reserved *SlugRegistry) (string, error) {
+	for attempt := 0; attempt < maxSlugAttempts; attempt++ {
+		slug, err := generator.NewSlug(func(slug string) (bool, error) {
+			if isReserved, err := reserved.IsReserved(slug); err != nil || isReserved {
+				return true, err
+			}
+			return taken(slug)
+		})
+		if err != nil {
+			return "", err
+		}
+		isReserved, err := reserved.IsReserved(slug)
+		if err != nil {
+			return "", err
+		}
+		if !isReserved {
+			return slug, nil
+		}
+	}
+	return "", ErrSlugSpaceExhausted
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"url-shortener/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeBlocklist(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestSlugRegistryIsReserved(t *testing.T) {
+	path := writeBlocklist(t, "# Blocked words\nBadword\n\nre:^spam[0-9]+$\nre:scam\n")
+	registry, err := NewSlugRegistry(nil, SlugRegistryConfig{BlocklistFile: path})
+	require.NoError(t, err)
+	registry.ReserveRoutes([]string{"/docs/*path", "/:shortLink", "/api/links/:shortLink"})
+
+	tests := map[string]bool{
+		"ping":       true, // Built in
+		"API":        true,
+		"docs":       true, // Route
+		"badword":    true,
+		"BADWORD":    true,
+		"badwords":   false, // Words match whole slugs
+		"spam42":     true,
+		"spam42x":    false,
+		"noscamhere": true, // Patterns match anywhere unless anchored
+		"shortLink":  false,
+		"hello":      false,
+	}
+	for slug, want := range tests {
+		reserved, err := registry.IsReserved(slug)
+		require.NoError(t, err)
+		assert.Equal(t, want, reserved, slug)
+	}
+
+	_, err = registry.Add("promo", false, "")
+	assert.Error(t, err, "a registry without a store can't be managed")
+
+	_, err = NewSlugRegistry(nil, SlugRegistryConfig{BlocklistFile: writeBlocklist(t, "fine\nre:(\n")})
+	assert.ErrorIs(t, err, ErrInvalidReservedSlug)
+	assert.ErrorContains(t, err, ":2:")
+	_, err = NewSlugRegistry(nil, SlugRegistryConfig{BlocklistFile: filepath.Join(t.TempDir(), "missing.txt")})
+	assert.Error(t, err)
+}
+
+func TestSlugRegistryAdminEntries(t *testing.T) {
+	repo := repositories.NewMemoryReservedSlugRepository()
+	registry, err := NewSlugRegistry(repo, SlugRegistryConfig{})
+	require.NoError(t, err)
+	// Another instance sharing the store picks changes up after its refresh interval
+	replica, err := NewSlugRegistry(repo, SlugRegistryConfig{RefreshInterval: time.Millisecond})
+	require.NoError(t, err)
+	reserved, err := replica.IsReserved("promo")
+	require.NoError(t, err)
+	assert.False(t, reserved)
+
+	entry, err := registry.Add(" Promo ", false, "spring campaign")
+	require.NoError(t, err)
+	assert.Equal(t, "promo", entry.Value)
+	assert.Equal(t, ReservedAdmin, entry.Source)
+	_, err = registry.Add("promo", false, "")
+	assert.ErrorIs(t, err, ErrReservedSlugExists)
+	_, err = registry.Add("no spaces", false, "")
+	assert.ErrorIs(t, err, ErrInvalidReservedSlug)
+	_, err = registry.Add("[", true, "")
+	assert.ErrorIs(t, err, ErrInvalidReservedSlug)
+
+	reserved, err = registry.IsReserved("PROMO")
+	require.NoError(t, err)
+	assert.True(t, reserved)
+	time.Sleep(2 * time.Millisecond)
+	reserved, err = replica.IsReserved("promo")
+	require.NoError(t, err)
+	assert.True(t, reserved)
+
+	entries, err := registry.List()
+	require.NoError(t, err)
+	assert.Equal(t, ReservedBuiltIn, entries[0].Source)
+	assert.Equal(t, *entry, entries[len(entries)-1])
+
+	require.NoError(t, registry.Remove(entry.ID))
+	assert.ErrorIs(t, registry.Remove(entry.ID), ErrReservedSlugNotFound)
+	reserved, err = registry.IsReserved("promo")
+	require.NoError(t, err)
+	assert.False(t, reserved)
+}
+
+func TestReservedSlugsInURLService(t *testing.T) {
+	registry, err := NewSlugRegistry(repositories.NewMemoryReservedSlugRepository(), SlugRegistryConfig{})
+	require.NoError(t, err)
+	_, err = registry.Add("promo", false, "")
+	require.NoError(t, err)
+	_, err = registry.Add("^promo[0-9]$", true, "")
+	require.NoError(t, err)
+
+	// Predict the first two sequential slugs and reserve the first
+	config := SequentialSlugConfig{Secret: "s"}
+	twin, err := NewSequentialSlugGenerator(repositories.NewMemorySequenceRepository(), config)
+	require.NoError(t, err)
+	first, err := twin.NewSlug(nil)
+	require.NoError(t, err)
+	second, err := twin.NewSlug(nil)
+	require.NoError(t, err)
+	_, err = registry.Add(first, false, "")
+	require.NoError(t, err)
+
+	generator, err := NewSequentialSlugGenerator(repositories.NewMemorySequenceRepository(), config)
+	require.NoError(t, err)
+	service := NewURLService(repositories.NewMemoryURLRepository(), generator, registry, ExpirationPolicy{})
+
+	_, err = service.CreateURL(CreateURLParams{OriginalURL: "https://example.com", CustomSlug: "Promo"})
+	assert.ErrorIs(t, err, ErrSlugReserved)
+
+	url, err := service.CreateURL(CreateURLParams{OriginalURL: "https://example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, second, url.ShortLink)
+
+	availability, err := service.CheckSlugAvailability("promo")
+	require.NoError(t, err)
+	assert.Equal(t, SlugReserved, availability.Reason)
+	for _, suggestion := range availability.Suggestions {
+		assert.NotRegexp(t, `^promo[0-9]$`, suggestion)
+	}
+}
@@ -0,0 +1,89 @@
+// services/slug_sequence.go
+package services
+
+import (
+	"errors"
+	"sync"
+	"url-shortener/repositories"
+)
+
+// slugSequence names the counter SequentialSlugGenerator leases from.
+const slugSequence = "slugs"
+
+// SequentialSlugConfig configures NewSequentialSlugGenerator. Zero values fall back to
+// defaults.
+type SequentialSlugConfig struct {
+	MinLength int    // Length of the first slugs, default 6; later ones grow as needed
+	Alphabet  string // Default DefaultSlugAlphabet
+	Secret    string // Keys the shuffle; keep it stable and equal across instances
+	BlockSize int64  // Values leased per round trip to the store, default 100
+}
+
+// SequentialSlugStats describes a SequentialSlugGenerator's current state.
+type SequentialSlugStats struct {
+	Issued    int64 `json:"issued"`
+	Leases    int64 `json:"leases"`
+	Remaining int64 `json:"remaining"` // Values left in the current block
+}
+
+// SequentialSlugGenerator encodes values from a block-leased counter with a SlugEncoder.
+// Every instance leases its own block, so generated slugs never collide and no existence
+// check is needed. Values of a block that is still unused at shutdown are skipped.
+type SequentialSlugGenerator struct {
+	sequences repositories.SequenceRepository
+	encoder   *SlugEncoder
+	blockSize int64
+
+	mu     sync.Mutex
+	next   int64
+	end    int64 // next == end means a new block is needed
+	issued int64
+	leases int64
+}
+
+func NewSequentialSlugGenerator(sequences repositories.SequenceRepository, config SequentialSlugConfig) (*SequentialSlugGenerator, error) {
+	if config.MinLength <= 0 {
+		config.MinLength = 6
+	}
+	if config.Alphabet == "" {
+		config.Alphabet = DefaultSlugAlphabet
+	}
+	if config.BlockSize <= 0 {
+		config.BlockSize = 100
+	}
+	if sequences == nil {
+		return nil, errors.New("sequential slugs need a sequence store")
+	}
+
+	encoder, err := NewSlugEncoder(config.Alphabet, config.MinLength, config.Secret)
+	if err != nil {
+		return nil, err
+	}
+	return &SequentialSlugGenerator{sequences: sequences, encoder: encoder, blockSize: config.BlockSize}, nil
+}
+
+// NewSlug never calls taken: the slug belongs to this instance alone. A custom slug may
+// still occupy it, which CreateURL handles by asking for the next one.
+func (g *SequentialSlugGenerator) NewSlug(func(slug string) (bool, error)) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.next == g.end {
+		start, err := g.sequences.Reserve(slugSequence, g.blockSize)
+		if err != nil {
+			return "", err
+		}
+		g.next, g.end = start, start+g.blockSize
+		g.leases++
+	}
+	value := g.next
+	g.next++
+	g.issued++
+	return g.encoder.Encode(value)
+}
+
+func (g *SequentialSlugGenerator) Stats() SequentialSlugStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return SequentialSlugStats{Issued: g.issued, Leases: g.leases, Remaining: g.end - g.next}
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"sync"
+	"testing"
+	"url-shortener/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlugEncoderRoundTrip(t *testing.T) {
+	encoder, err := NewSlugEncoder("abc", 2, "secret")
+	require.NoError(t, err)
+
+	// 9 two-character slugs, then 27 three-character ones
+	seen := make(map[string]bool)
+	for value := int64(0); value < 36; value++ {
+		slug, err := encoder.Encode(value)
+		require.NoError(t, err)
+		if value < 9 {
+			assert.Len(t, slug, 2)
+		} else {
+			assert.Len(t, slug, 3)
+		}
+		assert.False(t, seen[slug], "%s issued twice", slug)
+		seen[slug] = true
+
+		decoded, ok := encoder.Decode(slug)
+		require.True(t, ok)
+		assert.Equal(t, value, decoded)
+	}
+
+	_, ok := encoder.Decode("abz")
+	assert.False(t, ok)
+	_, ok = encoder.Decode("a")
+	assert.False(t, ok)
+	_, err = encoder.Encode(-1)
+	assert.Error(t, err)
+}
+
+func TestSlugEncoderSecret(t *testing.T) {
+	first, err := NewSlugEncoder(DefaultSlugAlphabet, 6, "one")
+	require.NoError(t, err)
+	second, err := NewSlugEncoder(DefaultSlugAlphabet, 6, "two")
+	require.NoError(t, err)
+
+	a, err := first.Encode(1000)
+	require.NoError(t, err)
+	b, err := first.Encode(1001)
+	require.NoError(t, err)
+	c, err := second.Encode(1000)
+	require.NoError(t, err)
+
+	// Consecutive values share no obvious prefix, and the secret changes the mapping
+	assert.NotEqual(t, a[:4], b[:4])
+	assert.NotEqual(t, a, c)
+
+	_, err = NewSlugEncoder("ab-", 6, "")
+	assert.Error(t, err)
+	_, err = NewSlugEncoder(DefaultSlugAlphabet, MaxGeneratedSlugLength+1, "")
+	assert.Error(t, err)
+}
+
+func TestSequentialSlugGeneratorSharesSequence(t *testing.T) {
+	sequences := repositories.NewMemorySequenceRepository()
+	config := SequentialSlugConfig{Secret: "s", BlockSize: 10}
+	replicas := make([]*SequentialSlugGenerator, 3)
+	for i := range replicas {
+		generator, err := NewSequentialSlugGenerator(sequences, config)
+		require.NoError(t, err)
+		replicas[i] = generator
+	}
+
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	var wg sync.WaitGroup
+	for _, generator := range replicas {
+		wg.Add(1)
+		go func(generator *SequentialSlugGenerator) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				slug, err := generator.NewSlug(func(string) (bool, error) {
+					t.Error("sequential slugs need no existence check")
+					return false, nil
+				})
+				if !assert.NoError(t, err) {
+					return
+				}
+				mu.Lock()
+				assert.False(t, seen[slug], "%s issued twice", slug)
+				seen[slug] = true
+				mu.Unlock()
+			}
+		}(generator)
+	}
+	wg.Wait()
+	assert.Len(t, seen, 300)
+
+	stats := replicas[0].Stats()
+	assert.Equal(t, int64(100), stats.Issued)
+	assert.Equal(t, int64(10), stats.Leases)
+	assert.Zero(t, stats.Remaining)
+
+	_, err := NewSequentialSlugGenerator(nil, config)
+	assert.Error(t, err)
+}
+
+func TestCreateURLSkipsOccupiedSequentialSlug(t *testing.T) {
+	sequences := repositories.NewMemorySequenceRepository()
+	config := SequentialSlugConfig{MinLength: 5, Secret: "s"}
+	// A twin generator on its own sequence predicts the first slug
+	twin, err := NewSequentialSlugGenerator(repositories.NewMemorySequenceRepository(), config)
+	require.NoError(t, err)
+	first, err := twin.NewSlug(nil)
+	require.NoError(t, err)
+	second, err := twin.NewSlug(nil)
+	require.NoError(t, err)
+
+	generator, err := NewSequentialSlugGenerator(sequences, config)
+	require.NoError(t, err)
+	repo := repositories.NewMemoryURLRepository()
+	service := NewURLService(repo, generator, nil, ExpirationPolicy{})
+
+	// A custom slug occupies the first generated slug
+	_, err = service.CreateURL(CreateURLParams{OriginalURL: "https://example.com/custom", CustomSlug: first})
+	require.NoError(t, err)
+
+	url, err := service.CreateURL(CreateURLParams{OriginalURL: "https://example.com/generated"})
+	require.NoError(t, err)
+	assert.Equal(t, second, url.ShortLink)
+}
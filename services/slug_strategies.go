@@ -0,0 +1,132 @@
+// services/slug_strategies.go
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"url-shortener/repositories"
+)
+
+// Slug generation strategies, chosen per server (SLUG_STRATEGY) or per request.
+const (
+	SlugStrategyRandom     = "random"
+	SlugStrategySequential = "sequential"
+	SlugStrategyWords      = "words"
+)
+
+// ErrUnknownSlugStrategy is returned when a request asks for a strategy the server doesn't offer.
+var ErrUnknownSlugStrategy = errors.New("slug strategy must be random, sequential or words")
+
+// SlugGeneratorConfig selects and configures the generators; see RandomSlugConfig and
+// SequentialSlugConfig for the fields. Length is the sequential generator's MinLength.
+type SlugGeneratorConfig struct {
+	Strategy        string // Default strategy, SlugStrategyRandom unless set
+	Length          int
+	MaxLength       int
+	Alphabet        string
+	GrowthThreshold float64
+	GrowthWindow    int
+	Secret          string
+	BlockSize       int64
+}
+
+// SlugStrategies holds a generator per strategy. It is a SlugGenerator itself, using the
+// default strategy, so services that don't let callers choose can take it as is.
+type SlugStrategies struct {
+	defaultStrategy string
+	generators      map[string]SlugGenerator
+}
+
+// NewSlugGenerator builds every strategy's generator. Without a sequence store the
+// sequential strategy is unavailable.
+func NewSlugGenerator(config SlugGeneratorConfig, sequences repositories.SequenceRepository) (*SlugStrategies, error) {
+	strategies := &SlugStrategies{
+		defaultStrategy: strings.ToLower(config.Strategy),
+		generators:      make(map[string]SlugGenerator),
+	}
+	if strategies.defaultStrategy == "" {
+		strategies.defaultStrategy = SlugStrategyRandom
+	}
+
+	random, err := NewRandomSlugGenerator(RandomSlugConfig{
+		Length:          config.Length,
+		MaxLength:       config.MaxLength,
+		Alphabet:        config.Alphabet,
+		GrowthThreshold: config.GrowthThreshold,
+		GrowthWindow:    config.GrowthWindow,
+	})
+	if err != nil {
+		return nil, err
+	}
+	strategies.generators[SlugStrategyRandom] = random
+
+	words, err := NewWordSlugGenerator()
+	if err != nil {
+		return nil, err
+	}
+	strategies.generators[SlugStrategyWords] = words
+
+	if sequences != nil {
+		sequential, err := NewSequentialSlugGenerator(sequences, SequentialSlugConfig{
+			MinLength: config.Length,
+			Alphabet:  config.Alphabet,
+			Secret:    config.Secret,
+			BlockSize: config.BlockSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+		strategies.generators[SlugStrategySequential] = sequential
+	}
+
+	if _, ok := strategies.generators[strategies.defaultStrategy]; !ok {
+		return nil, fmt.Errorf("unknown or unavailable slug strategy %q (expected %s, %s or %s)",
+			config.Strategy, SlugStrategyRandom, SlugStrategySequential, SlugStrategyWords)
+	}
+	return strategies, nil
+}
+
+func (s *SlugStrategies) NewSlug(taken func(slug string) (bool, error)) (string, error) {
+	return s.generators[s.defaultStrategy].NewSlug(taken)
+}
+
+// Strategy returns the generator for name; "" selects the default.
+func (s *SlugStrategies) Strategy(name string) (SlugGenerator, error) {
+	if name == "" {
+		name = s.defaultStrategy
+	}
+	generator, ok := s.generators[strings.ToLower(name)]
+	if !ok {
+		return nil, ErrUnknownSlugStrategy
+	}
+	return generator, nil
+}
+
+// Stats reports the default strategy and each generator's counters.
+func (s *SlugStrategies) Stats() map[string]any {
+	stats := map[string]any{"default": s.defaultStrategy}
+	for name, generator := range s.generators {
+		switch generator := generator.(type) {
+		case *RandomSlugGenerator:
+			stats[name] = generator.Stats()
+		case *SequentialSlugGenerator:
+			stats[name] = generator.Stats()
+		case *WordSlugGenerator:
+			stats[name] = generator.Stats()
+		}
+	}
+	return stats
+}
+
+// slugGenerator picks the generator a request asked for. Generators other than
+// SlugStrategies only serve requests that leave the strategy to the server.
+func slugGenerator(slugs SlugGenerator, strategy string) (SlugGenerator, error) {
+	if strategies, ok := slugs.(*SlugStrategies); ok {
+		return strategies.Strategy(strategy)
+	}
+	if strategy != "" {
+		return nil, ErrUnknownSlugStrategy
+	}
+	return slugs, nil
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"regexp"
+	"testing"
+	"url-shortener/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSlugGenerator(t *testing.T) {
+	strategies, err := NewSlugGenerator(SlugGeneratorConfig{}, nil)
+	require.NoError(t, err)
+	generator, err := strategies.Strategy("")
+	require.NoError(t, err)
+	assert.IsType(t, &RandomSlugGenerator{}, generator)
+	_, err = strategies.Strategy(SlugStrategySequential)
+	assert.ErrorIs(t, err, ErrUnknownSlugStrategy)
+
+	strategies, err = NewSlugGenerator(SlugGeneratorConfig{Strategy: "Sequential"}, repositories.NewMemorySequenceRepository())
+	require.NoError(t, err)
+	generator, err = strategies.Strategy("")
+	require.NoError(t, err)
+	assert.IsType(t, &SequentialSlugGenerator{}, generator)
+	generator, err = strategies.Strategy("WORDS")
+	require.NoError(t, err)
+	assert.IsType(t, &WordSlugGenerator{}, generator)
+
+	_, err = NewSlugGenerator(SlugGeneratorConfig{Strategy: "sequential"}, nil)
+	assert.Error(t, err)
+	_, err = NewSlugGenerator(SlugGeneratorConfig{Strategy: "hashids"}, nil)
+	assert.Error(t, err)
+}
+
+func TestCreateURLSlugStrategy(t *testing.T) {
+	strategies, err := NewSlugGenerator(SlugGeneratorConfig{}, repositories.NewMemorySequenceRepository())
+	require.NoError(t, err)
+	service := NewURLService(repositories.NewMemoryURLRepository(), strategies, nil, ExpirationPolicy{})
+
+	url, err := service.CreateURL(CreateURLParams{OriginalURL: "https://example.com", SlugStrategy: SlugStrategyWords})
+	require.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile(`^[a-z]+-[a-z]+-[1-9][0-9]$`), url.ShortLink)
+
+	url, err = service.CreateURL(CreateURLParams{OriginalURL: "https://example.com"})
+	require.NoError(t, err)
+	assert.Len(t, url.ShortLink, 6)
+
+	_, err = service.CreateURL(CreateURLParams{OriginalURL: "https://example.com", SlugStrategy: "emoji"})
+	assert.ErrorIs(t, err, ErrUnknownSlugStrategy)
+
+	// A plain generator only serves the server default
+	_, err = NewURLService(repositories.NewMemoryURLRepository(), nil, nil, ExpirationPolicy{}).CreateURL(CreateURLParams{OriginalURL: "https://example.com", SlugStrategy: SlugStrategyWords})
+	assert.ErrorIs(t, err, ErrUnknownSlugStrategy)
+}
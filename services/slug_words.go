@@ -0,0 +1,169 @@
+// services/slug_words.go
+package services
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"url-shortener/utils"
+)
+
+//go:embed wordlists/*.txt
+var wordlists embed.FS
+
+// wordSlugDigits is how many digits end a word slug; callers that keep colliding get more,
+// up to wordSlugMaxDigits.
+const (
+	wordSlugDigits    = 2
+	wordSlugMaxDigits = 4
+	// wordSlugAttemptsPerDigit candidates are tried before another digit is added
+	wordSlugAttemptsPerDigit = 20
+)
+
+// WordSlugStats describes a WordSlugGenerator's current state.
+type WordSlugStats struct {
+	Adjectives int   `json:"adjectives"`
+	Nouns      int   `json:"nouns"`
+	Attempts   int64 `json:"attempts"`
+	Collisions int64 `json:"collisions"`
+}
+
+// WordSlugGenerator builds slugs that are easy to read aloud, like brave-otter-42, from the
+// embedded word lists. Words and combinations containing blocked terms are never used.
+type WordSlugGenerator struct {
+	adjectives []string
+	nouns      []string
+	blocked    []string
+
+	attempts   atomic.Int64
+	collisions atomic.Int64
+}
+
+func NewWordSlugGenerator() (*WordSlugGenerator, error) {
+	blocked, err := readWordlist("blocked.txt")
+	if err != nil {
+		return nil, err
+	}
+	g := &WordSlugGenerator{blocked: blocked}
+	if g.adjectives, err = g.readClean("adjectives.txt"); err != nil {
+		return nil, err
+	}
+	if g.nouns, err = g.readClean("nouns.txt"); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (g *WordSlugGenerator) NewSlug(taken func(slug string) (bool, error)) (string, error) {
+	for attempt := 0; attempt < maxSlugAttempts; attempt++ {
+		digits := min(wordSlugDigits+attempt/wordSlugAttemptsPerDigit, wordSlugMaxDigits)
+		slug, err := g.candidate(digits)
+		if err != nil {
+			return "", err
+		}
+		if g.isBlocked(slug) {
+			continue
+		}
+
+		g.attempts.Add(1)
+		collided, err := taken(slug)
+		if err != nil {
+			return "", err
+		}
+		if !collided {
+			return slug, nil
+		}
+		g.collisions.Add(1)
+	}
+	return "", ErrSlugSpaceExhausted
+}
+
+func (g *WordSlugGenerator) Stats() WordSlugStats {
+	return WordSlugStats{
+		Adjectives: len(g.adjectives),
+		Nouns:      len(g.nouns),
+		Attempts:   g.attempts.Load(),
+		Collisions: g.collisions.Load(),
+	}
+}
+
+// candidate joins a random adjective and noun with a number of the given width that has no
+// leading zero.
+func (g *WordSlugGenerator) candidate(digits int) (string, error) {
+	adjective, err := utils.RandomIndex(len(g.adjectives))
+	if err != nil {
+		return "", err
+	}
+	noun, err := utils.RandomIndex(len(g.nouns))
+	if err != nil {
+		return "", err
+	}
+	low := 1
+	for i := 1; i < digits; i++ {
+		low *= 10
+	}
+	number, err := utils.RandomIndex(9 * low)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%s-%d", g.adjectives[adjective], g.nouns[noun], low+number), nil
+}
+
+// isBlocked reports whether a word or slug contains a blocked term. Whole words are
+// compared exactly; across word boundaries only terms of four or more letters count, since
+// shorter ones turn up inside too many innocent words.
+func (g *WordSlugGenerator) isBlocked(slug string) bool {
+	words := strings.Split(strings.ToLower(slug), "-")
+	compact := strings.Join(words, "")
+	for _, term := range g.blocked {
+		for _, word := range words {
+			if word == term {
+				return true
+			}
+		}
+		if len(term) >= 4 && strings.Contains(compact, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// readClean loads a word list without the words isBlocked rejects.
+func (g *WordSlugGenerator) readClean(name string) ([]string, error) {
+	words, err := readWordlist(name)
+	if err != nil {
+		return nil, err
+	}
+	clean := words[:0]
+	for _, word := range words {
+		if isAlphanumeric(word) && !g.isBlocked(word) {
+			clean = append(clean, word)
+		}
+	}
+	if len(clean) == 0 {
+		return nil, fmt.Errorf("word list %s is empty", name)
+	}
+	return clean, nil
+}
+
+// readWordlist returns the lower-cased lines of an embedded list, skipping blank lines and
+// # comments.
+func readWordlist(name string) ([]string, error) {
+	file, err := wordlists.Open("wordlists/" + name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if line != "" && !strings.HasPrefix(line, "#") {
+			words = append(words, line)
+		}
+	}
+	return words, scanner.Err()
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWordSlugGenerator(t *testing.T) {
+	generator, err := NewWordSlugGenerator()
+	require.NoError(t, err)
+
+	for _, words := range [][]string{generator.adjectives, generator.nouns} {
+		for _, word := range words {
+			assert.False(t, generator.isBlocked(word), word)
+		}
+	}
+
+	// Collisions add digits
+	taken := 0
+	slug, err := generator.NewSlug(func(string) (bool, error) {
+		taken++
+		return taken <= wordSlugAttemptsPerDigit, nil
+	})
+	require.NoError(t, err)
+	assert.Regexp(t, `^[a-z]+-[a-z]+-[1-9][0-9]{2}$`, slug)
+	assert.LessOrEqual(t, len(slug), 32)
+
+	stats := generator.Stats()
+	assert.Equal(t, int64(wordSlugAttemptsPerDigit+1), stats.Attempts)
+	assert.Equal(t, int64(wordSlugAttemptsPerDigit), stats.Collisions)
+
+	_, err = generator.NewSlug(func(string) (bool, error) { return true, nil })
+	assert.ErrorIs(t, err, ErrSlugSpaceExhausted)
+}
+
+func TestWordSlugBlocklist(t *testing.T) {
+	generator := &WordSlugGenerator{blocked: []string{"tit", "shit"}}
+
+	assert.True(t, generator.isBlocked("tit-otter-42"))
+	assert.True(t, generator.isBlocked("brash-itch-42"), "terms across word boundaries count")
+	assert.False(t, generator.isBlocked("petite-otter-42"), "short terms only match whole words")
+}
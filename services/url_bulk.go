@@ -0,0 +1,56 @@
+// services/url_bulk.go
+package services
+
+import (
+	"errors"
+	"url-shortener/models"
+)
+
+// ErrBatchRejected is returned by an atomic CreateURLs when at least one item is invalid;
+// the per-item results say which, and nothing was stored.
+var ErrBatchRejected = errors.New("batch rejected: at least one item is invalid")
+
+// BulkResult is the outcome for one item of CreateURLs, in request order.
+type BulkResult struct {
+	URL *models.URL // Set when the item was created
+	Err error
+}
+
+// CreateURLs shortens many URLs in one call. With atomic set the batch is stored in a single
+// transaction and either every item is created or none is; otherwise each item succeeds or
+// fails on its own.
+func (s *urlService) CreateURLs(items []CreateURLParams, atomic bool) ([]BulkResult, error) {
+	results := make([]BulkResult, len(items))
+	if !atomic {
+		for i, params := range items {
+			results[i].URL, results[i].Err = s.CreateURL(params)
+		}
+		return results, nil
+	}
+
+	// Validate the whole batch up front, reserving slugs so items can't collide with each other
+	urls := make([]*models.URL, len(items))
+	claimed := make(map[string]bool, len(items))
+	rejected := false
+	for i, params := range items {
+		url, err := s.newURL(params, claimed)
+		if err != nil {
+			results[i].Err = err
+			rejected = true
+			continue
+		}
+		urls[i] = url
+		claimed[url.ShortLink] = true
+	}
+	if rejected {
+		return results, ErrBatchRejected
+	}
+
+	if err := s.urlRepo.CreateBatch(urls); err != nil {
+		return nil, err
+	}
+	for i, url := range urls {
+		results[i].URL = url
+	}
+	return results, nil
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+	"url-shortener/models"
+	"url-shortener/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateURLsPartial(t *testing.T) {
+	service := NewURLService(repositories.NewMemoryURLRepository(), nil, nil, ExpirationPolicy{})
+	owner := &models.APIKey{ID: 4}
+
+	results, err := service.CreateURLs([]CreateURLParams{
+		{OriginalURL: "https://example.com/a", CustomSlug: "camp1", Owner: owner},
+		{OriginalURL: "ftp://example.com/b"},
+		{OriginalURL: "https://example.com/c", CustomSlug: "camp1"},
+		{OriginalURL: "https://example.com/d"},
+	}, false)
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, &owner.ID, results[0].URL.OwnerID)
+	assert.ErrorIs(t, results[1].Err, ErrInvalidURL)
+	assert.ErrorIs(t, results[2].Err, ErrSlugTaken)
+	assert.NoError(t, results[3].Err)
+	assert.Len(t, results[3].URL.ShortLink, 6)
+}
+
+func TestCreateURLsAtomic(t *testing.T) {
+	service := NewURLService(repositories.NewMemoryURLRepository(), nil, nil, ExpirationPolicy{})
+
+	// Duplicate slugs within the batch reject it, and nothing is stored
+	results, err := service.CreateURLs([]CreateURLParams{
+		{OriginalURL: "https://example.com/a", CustomSlug: "camp1"},
+		{OriginalURL: "https://example.com/b", CustomSlug: "camp1"},
+	}, true)
+	assert.ErrorIs(t, err, ErrBatchRejected)
+	require.Len(t, results, 2)
+	assert.NoError(t, results[0].Err)
+	assert.Nil(t, results[0].URL)
+	assert.ErrorIs(t, results[1].Err, ErrSlugTaken)
+	exists, err := service.IsCustomSlugExists("camp1")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	results, err = service.CreateURLs([]CreateURLParams{
+		{OriginalURL: "https://example.com/a", CustomSlug: "camp1"},
+		{OriginalURL: "https://example.com/b"},
+	}, true)
+	require.NoError(t, err)
+	for _, result := range results {
+		assert.NoError(t, result.Err)
+		assert.NotZero(t, result.URL.ID)
+	}
+}
@@ -0,0 +1,159 @@
+// services/url_listing.go
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"url-shortener/models"
+	"url-shortener/repositories"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+	defaultSort     = "-created_at"
+)
+
+// ErrInvalidListQuery wraps every validation error from ListURLs.
+var ErrInvalidListQuery = errors.New("invalid list query")
+
+// LinkFilter selects links for listing and export. Zero values mean "no filter".
+type LinkFilter struct {
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Status        string // "active", "expired" or "" for both
+	Domain        string
+	SlugPrefix    string
+	Search        string
+}
+
+// ListURLsParams filters and pages GET /api/links.
+type ListURLsParams struct {
+	LinkFilter
+	Sort   string // Field name, prefixed with "-" for descending
+	Cursor string // NextCursor of the previous page
+	Limit  int
+}
+
+type URLPage struct {
+	URLs       []models.URL
+	NextCursor string // Empty on the last page
+}
+
+// pageCursor is the opaque cursor handed to clients. It records the sort it was issued for,
+// so a cursor can't be replayed against a different order.
+type pageCursor struct {
+	Sort      string    `json:"s"`
+	Time      time.Time `json:"t,omitempty"`
+	ShortLink string    `json:"l,omitempty"`
+	ID        uint      `json:"i"`
+}
+
+var sortFields = map[string]repositories.URLSortField{
+	"created_at":      repositories.SortByCreatedAt,
+	"expiration_date": repositories.SortByExpirationDate,
+	"short_link":      repositories.SortByShortLink,
+}
+
+// ListURLs pages through links visible to actor: admins see every link, other keys only
+// the links they own.
+func (s *urlService) ListURLs(params ListURLsParams, actor *models.APIKey) (*URLPage, error) {
+	query, err := filterQuery(params.LinkFilter, actor)
+	if err != nil {
+		return nil, err
+	}
+	query.Limit = params.Limit
+
+	sort := params.Sort
+	if sort == "" {
+		sort = defaultSort
+	}
+	sortBy, ok := sortFields[strings.TrimPrefix(sort, "-")]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown sort %q", ErrInvalidListQuery, sort)
+	}
+	query.SortBy = sortBy
+	query.Descending = strings.HasPrefix(sort, "-")
+
+	switch {
+	case query.Limit == 0:
+		query.Limit = DefaultPageSize
+	case query.Limit < 0 || query.Limit > MaxPageSize:
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidListQuery, MaxPageSize)
+	}
+
+	if params.Cursor != "" {
+		cursor, err := decodePageCursor(params.Cursor, sort)
+		if err != nil {
+			return nil, err
+		}
+		query.After = cursor
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	query.Limit++
+	urls, err := s.urlRepo.List(query)
+	if err != nil {
+		return nil, err
+	}
+	page := &URLPage{URLs: urls}
+	if len(urls) == query.Limit {
+		page.URLs = urls[:len(urls)-1]
+		last := repositories.CursorFor(&page.URLs[len(page.URLs)-1], sortBy)
+		page.NextCursor = encodePageCursor(last, sort)
+	}
+	return page, nil
+}
+
+// filterQuery turns filter into a repository query scoped to the links actor may see.
+func filterQuery(filter LinkFilter, actor *models.APIKey) (repositories.URLQuery, error) {
+	if actor == nil {
+		return repositories.URLQuery{}, ErrForbidden
+	}
+
+	query := repositories.URLQuery{
+		CreatedAfter:  filter.CreatedAfter,
+		CreatedBefore: filter.CreatedBefore,
+		Domain:        strings.TrimSpace(filter.Domain),
+		SlugPrefix:    filter.SlugPrefix,
+		Search:        filter.Search,
+		Now:           time.Now(),
+	}
+	if !actor.IsAdmin {
+		query.OwnerID = &actor.ID
+	}
+
+	switch filter.Status {
+	case "", "all":
+	case "active", "expired":
+		expired := filter.Status == "expired"
+		query.Expired = &expired
+	default:
+		return query, fmt.Errorf("%w: status must be active, expired or all", ErrInvalidListQuery)
+	}
+	return query, nil
+}
+
+func encodePageCursor(cursor repositories.URLCursor, sort string) string {
+	data, _ := json.Marshal(pageCursor{Sort: sort, Time: cursor.Time, ShortLink: cursor.ShortLink, ID: cursor.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageCursor(encoded, sort string) (*repositories.URLCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidListQuery)
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidListQuery)
+	}
+	if cursor.Sort != sort {
+		return nil, fmt.Errorf("%w: cursor was issued for sort %q", ErrInvalidListQuery, cursor.Sort)
+	}
+	return &repositories.URLCursor{Time: cursor.Time, ShortLink: cursor.ShortLink, ID: cursor.ID}, nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"testing"
+	"url-shortener/models"
+	"url-shortener/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListURLs(t *testing.T) {
+	service := NewURLService(repositories.NewMemoryURLRepository(), nil, nil, ExpirationPolicy{})
+	alice := &models.APIKey{ID: 1}
+	bob := &models.APIKey{ID: 2}
+	admin := &models.APIKey{ID: 3, IsAdmin: true}
+
+	for _, slug := range []string{"aaa", "bbb", "ccc", "ddd", "eee"} {
+		_, err := service.CreateURL(CreateURLParams{OriginalURL: "https://Docs.Example.com/" + slug, CustomSlug: slug, Owner: alice})
+		require.NoError(t, err)
+	}
+	_, err := service.CreateURL(CreateURLParams{OriginalURL: "https://other.org/x", CustomSlug: "bobs", Owner: bob})
+	require.NoError(t, err)
+
+	// Walk alice's links two at a time
+	var slugs []string
+	params := ListURLsParams{Sort: "short_link", Limit: 2}
+	for {
+		page, err := service.ListURLs(params, alice)
+		require.NoError(t, err)
+		for _, url := range page.URLs {
+			slugs = append(slugs, url.ShortLink)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		params.Cursor = page.NextCursor
+	}
+	assert.Equal(t, []string{"aaa", "bbb", "ccc", "ddd", "eee"}, slugs)
+
+	page, err := service.ListURLs(ListURLsParams{}, admin)
+	require.NoError(t, err)
+	assert.Len(t, page.URLs, 6)
+	assert.Empty(t, page.NextCursor)
+
+	page, err = service.ListURLs(ListURLsParams{LinkFilter: LinkFilter{Domain: "docs.example.com", Search: "ccc"}}, admin)
+	require.NoError(t, err)
+	if assert.Len(t, page.URLs, 1) {
+		assert.Equal(t, "ccc", page.URLs[0].ShortLink)
+	}
+
+	page, err = service.ListURLs(ListURLsParams{LinkFilter: LinkFilter{Status: "expired"}}, admin)
+	require.NoError(t, err)
+	assert.Empty(t, page.URLs)
+
+	// A cursor is only valid for the sort it was issued with
+	page, err = service.ListURLs(ListURLsParams{Sort: "short_link", Limit: 1}, alice)
+	require.NoError(t, err)
+	_, err = service.ListURLs(ListURLsParams{Sort: "-short_link", Cursor: page.NextCursor}, alice)
+	assert.ErrorIs(t, err, ErrInvalidListQuery)
+
+	for _, params := range []ListURLsParams{{Sort: "owner_id"}, {LinkFilter: LinkFilter{Status: "deleted"}}, {Limit: MaxPageSize + 1}, {Cursor: "!!"}} {
+		_, err = service.ListURLs(params, alice)
+		assert.ErrorIs(t, err, ErrInvalidListQuery, "%+v", params)
+	}
+
+	_, err = service.ListURLs(ListURLsParams{}, nil)
+	assert.ErrorIs(t, err, ErrForbidden)
+}
@@ -3,93 +3,397 @@ package services
 
 import (
 	"errors"
+	neturl "net/url"
+	"strings"
 	"time"
+	"url-shortener/logging"
 	"url-shortener/models"
 	"url-shortener/repositories"
-	"url-shortener/utils"
 )
 
+// DefaultExpiration is applied when a URL is created without an explicit expiration date,
+// unless ExpirationPolicy.DefaultTTL says otherwise.
+const DefaultExpiration = 24 * time.Hour
+
+// Errors returned by URLService. Controllers map these to HTTP status codes.
+var (
+	ErrURLNotFound  = errors.New("short URL not found")
+	ErrSlugTaken    = errors.New("custom slug already exists")
+	ErrSlugInvalid  = errors.New("custom slug must be 3-8 alphanumeric characters")
+	ErrSlugReserved = errors.New("custom slug is reserved")
+	ErrURLExpired   = errors.New("URL has expired")
+	ErrURLDeleted   = errors.New("URL has been deleted")
+	ErrInvalidURL   = errors.New("URL must start with http:// or https://")
+
+	ErrNothingToUpdate   = errors.New("no fields to update")
+	ErrInvalidExpiration = errors.New("expiration date must be in the future")
+	ErrInvalidActivation = errors.New("activation time must be before the expiration date")
+	ErrNotYetActive      = errors.New("URL is not active yet")
+)
+
+// NotActiveError is returned by GetURL for links whose activation time hasn't come yet. It
+// matches ErrNotYetActive.
+type NotActiveError struct {
+	ActivatesAt time.Time
+}
+
+func (e *NotActiveError) Error() string {
+	return ErrNotYetActive.Error()
+}
+
+func (e *NotActiveError) Unwrap() error {
+	return ErrNotYetActive
+}
+
+// UnavailableError is returned by GetURL for links that exist but can't be followed anymore,
+// carrying what the visitor should see instead. It matches its Reason. Links have no disabled
+// state or click limit, so expiry and deletion are the only reasons.
+type UnavailableError struct {
+	Reason      error  // ErrURLExpired or ErrURLDeleted
+	FallbackURL string // The link's own fallback; "" if it has none
+}
+
+func (e *UnavailableError) Error() string {
+	return e.Reason.Error()
+}
+
+func (e *UnavailableError) Unwrap() error {
+	return e.Reason
+}
+
+// CreateURLParams describes a link to create. Zero values select the defaults.
+type CreateURLParams struct {
+	OriginalURL    string
+	CustomSlug     string
+	ExpirationDate time.Time      // models.NeverExpires for a permanent link
+	ActivatesAt    time.Time      // The link doesn't resolve before this; zero for right away
+	FallbackURL    string         // Destination once the link expired or was deleted; optional
+	Owner          *models.APIKey // nil for anonymous links
+	SlugStrategy   string         // Generator for links without CustomSlug; "" for the server default
+}
+
+// UpdateURLParams lists the attributes to change; nil fields are left untouched.
+type UpdateURLParams struct {
+	OriginalURL    *string
+	ExpirationDate *time.Time
+	ActivatesAt    *time.Time // A zero time activates the link right away
+	FallbackURL    *string    // "" removes the link's fallback
+}
+
+// URLService methods that modify a link take the acting API key (nil when anonymous)
+// and return ErrForbidden unless CanModify allows it.
 type URLService interface {
-	CreateURL(originalURL, customSlug string, expirationDate time.Time) (*models.URL, error)
+	CreateURL(params CreateURLParams) (*models.URL, error)
+	CreateURLs(items []CreateURLParams, atomic bool) ([]BulkResult, error)
 	GetURL(shortLink string) (*models.URL, error)
-	DeleteURL(shortLink string) error
-	IsCustomSlugExists(customSlug string) bool
-	SetExpirationDate(shortLink string, expirationDate time.Time) (*models.URL, error)
+	DeleteURL(shortLink string, actor *models.APIKey) error
+	IsCustomSlugExists(customSlug string) (bool, error)
+	CheckSlugAvailability(customSlug string) (*SlugAvailability, error)
+	UpdateURL(shortLink string, params UpdateURLParams, actor *models.APIKey) (*models.URL, error)
+	ListURLs(params ListURLsParams, actor *models.APIKey) (*URLPage, error)
+	Ping() error
 }
 
 type urlService struct {
-	urlRepo repositories.URLRepository
+	urlRepo  repositories.URLRepository
+	slugs    SlugGenerator
+	reserved *SlugRegistry
+	expiry   ExpirationPolicy
 }
 
-func NewURLService(urlRepo repositories.URLRepository) URLService {
-	return &urlService{urlRepo: urlRepo}
+// NewURLService uses slugs for links created without a custom slug; nil selects a
+// RandomSlugGenerator with default settings. A nil registry reserves the built-in names only.
+func NewURLService(urlRepo repositories.URLRepository, slugs SlugGenerator, reserved *SlugRegistry, expiry ExpirationPolicy) URLService {
+	return &urlService{urlRepo: urlRepo, slugs: orDefaultSlugGenerator(slugs), reserved: orDefaultSlugRegistry(reserved), expiry: expiry}
 }
 
-func (s *urlService) CreateURL(originalURL, customSlug string, expirationDate time.Time) (*models.URL, error) {
+// CreateURL stores a new short URL. A zero ExpirationDate falls back to the policy's default.
+func (s *urlService) CreateURL(params CreateURLParams) (*models.URL, error) {
+	url, err := s.newURL(params, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = s.urlRepo.Create(url)
+		if !errors.Is(err, repositories.ErrDuplicateShortLink) || params.CustomSlug != "" || attempt == maxSlugAttempts {
+			break
+		}
+		// A concurrent request or, for sequential slugs, a custom slug took the generated
+		// slug after all; generate another
+		generator, err := slugGenerator(s.slugs, params.SlugStrategy)
+		if err != nil {
+			return nil, err
+		}
+		if url.ShortLink, err = generateSlug(generator, slugTaken(s.urlRepo, nil), s.reserved); err != nil {
+			return nil, err
+		}
+	}
+	if errors.Is(err, repositories.ErrDuplicateShortLink) {
+		if params.CustomSlug != "" {
+			return nil, ErrSlugTaken
+		}
+		return nil, ErrSlugSpaceExhausted // Every generated slug was taken by the time it was stored
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return url, nil
+}
+
+// newURL validates params and builds the link to store, generating a slug when none was
+// requested. Slugs in claimed count as taken, for batches that are stored together.
+func (s *urlService) newURL(params CreateURLParams, claimed map[string]bool) (*models.URL, error) {
+	if !isHTTPURL(params.OriginalURL) || params.FallbackURL != "" && !isHTTPURL(params.FallbackURL) {
+		return nil, ErrInvalidURL
+	}
+
+	expirationDate, err := s.expiry.expirationDate(params.ExpirationDate)
+	if err != nil {
+		return nil, err
+	}
+	if !params.ActivatesAt.IsZero() && !params.ActivatesAt.Before(expirationDate) {
+		return nil, ErrInvalidActivation
+	}
+
 	var shortLink string
-	if customSlug != "" {
-		if s.urlRepo.ExistsByShortLink(customSlug) {
-			return nil, errors.New("custom slug already exists")
+	if customSlug := params.CustomSlug; customSlug != "" {
+		if slugFormatReason(customSlug) != "" {
+			return nil, ErrSlugInvalid
+		}
+		reserved, err := s.reserved.IsReserved(customSlug)
+		if err != nil {
+			return nil, err
+		}
+		if reserved {
+			return nil, ErrSlugReserved
+		}
+		exists, err := s.urlRepo.ExistsByShortLink(customSlug)
+		if err != nil {
+			return nil, err
+		}
+		if exists || claimed[customSlug] {
+			return nil, ErrSlugTaken
 		}
 		shortLink = customSlug
 	} else {
-		shortLink = utils.GenerateRandomSlug(6)
-		// Keep generating until we find a unique slug
-		for s.urlRepo.ExistsByShortLink(shortLink) {
-			shortLink = utils.GenerateRandomSlug(6)
+		generator, err := slugGenerator(s.slugs, params.SlugStrategy)
+		if err != nil {
+			return nil, err
+		}
+		if shortLink, err = generateSlug(generator, slugTaken(s.urlRepo, claimed), s.reserved); err != nil {
+			return nil, err
 		}
 	}
 
 	url := &models.URL{
-		OriginalURL:    originalURL,
-		ShortLink:      shortLink,
-		CreationDate:   time.Now(),
-		ExpirationDate: expirationDate,
+		OriginalURL:     params.OriginalURL,
+		ShortLink:       shortLink,
+		ExpirationDate:  expirationDate,
+		DestinationHost: destinationHost(params.OriginalURL),
+		FallbackURL:     params.FallbackURL,
 	}
-
-	if err := s.urlRepo.Create(url); err != nil {
-		return nil, err
+	if !params.ActivatesAt.IsZero() {
+		url.ActivatesAt = &params.ActivatesAt
+	}
+	if params.Owner != nil {
+		url.OwnerID = &params.Owner.ID
 	}
-
 	return url, nil
 }
 
+// slugTaken reports whether a generated slug is stored already or claimed by an earlier
+// item of the same batch.
+func slugTaken(urlRepo repositories.URLRepository, claimed map[string]bool) func(string) (bool, error) {
+	return func(slug string) (bool, error) {
+		if claimed[slug] {
+			return true, nil
+		}
+		return urlRepo.ExistsByShortLink(slug)
+	}
+}
+
+// GetURL resolves a short link. Before its activation time it returns a *NotActiveError.
+// Expired links are deleted, and for them, until they are purged, it returns an
+// *UnavailableError matching ErrURLExpired; for links deleted before they expired, one
+// matching ErrURLDeleted.
 func (s *urlService) GetURL(shortLink string) (*models.URL, error) {
 	url, err := s.urlRepo.FindByShortLink(shortLink)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, s.unavailable(shortLink)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	if url.ExpirationDate.Before(time.Now()) {
-		s.urlRepo.Delete(url)
-		return nil, errors.New("url has expired")
+	now := time.Now()
+	if !url.IsActive(now) {
+		return nil, &NotActiveError{ActivatesAt: *url.ActivatesAt}
+	}
+	if url.ExpirationDate.Before(now) {
+		if err := s.urlRepo.Delete(url); err != nil {
+			// The URL is still expired from the client's perspective, so only log the failure.
+			logging.Log.WithError(err).WithField("short_link", shortLink).Error("Failed to delete expired URL")
+		}
+		return nil, &UnavailableError{Reason: ErrURLExpired, FallbackURL: url.FallbackURL}
 	}
 
 	return url, nil
 }
 
-func (s *urlService) DeleteURL(shortLink string) error {
-	url, err := s.urlRepo.FindByShortLink(shortLink)
+// unavailable explains why a short link without a live link can't be followed. Expired links
+// may have been deleted by the expiry sweeper, so they count as expired rather than deleted.
+func (s *urlService) unavailable(shortLink string) error {
+	tombstone, err := s.urlRepo.FindDeletedByShortLink(shortLink)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrURLNotFound
+	}
+	if err != nil {
+		return err
+	}
+	reason := ErrURLExpired
+	if !tombstone.ExpirationDate.Before(time.Now()) {
+		reason = ErrURLDeleted
+	}
+	return &UnavailableError{Reason: reason, FallbackURL: tombstone.FallbackURL}
+}
+
+func (s *urlService) DeleteURL(shortLink string, actor *models.APIKey) error {
+	url, err := s.findByShortLink(shortLink)
 	if err != nil {
 		return err
 	}
+	if !CanModify(actor, url) {
+		return ErrForbidden
+	}
 	return s.urlRepo.Delete(url)
 }
 
-func (s *urlService) IsCustomSlugExists(customSlug string) bool {
+func (s *urlService) IsCustomSlugExists(customSlug string) (bool, error) {
 	return s.urlRepo.ExistsByShortLink(customSlug)
 }
 
-func (s *urlService) SetExpirationDate(shortLink string, expirationDate time.Time) (*models.URL, error) {
-	url, err := s.urlRepo.FindByShortLink(shortLink)
+// CheckSlugAvailability reports whether customSlug can be claimed and, if not, why, along
+// with a few available alternatives.
+func (s *urlService) CheckSlugAvailability(customSlug string) (*SlugAvailability, error) {
+	result := &SlugAvailability{Slug: customSlug, Reason: slugFormatReason(customSlug)}
+	if result.Reason == "" {
+		reserved, err := s.reserved.IsReserved(customSlug)
+		if err != nil {
+			return nil, err
+		}
+		if reserved {
+			result.Reason = SlugReserved
+		}
+	}
+	if result.Reason == "" {
+		exists, err := s.IsCustomSlugExists(customSlug)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			result.Available = true
+			return result, nil
+		}
+		result.Reason = SlugTaken
+	}
+
+	for _, candidate := range slugCandidates(customSlug) {
+		reserved, err := s.reserved.IsReserved(candidate)
+		if err != nil {
+			return nil, err
+		}
+		exists, err := s.IsCustomSlugExists(candidate)
+		if err != nil {
+			return nil, err
+		}
+		if !exists && !reserved {
+			result.Suggestions = append(result.Suggestions, candidate)
+		}
+		if len(result.Suggestions) == maxSlugSuggestions {
+			break
+		}
+	}
+	return result, nil
+}
+
+func (s *urlService) UpdateURL(shortLink string, params UpdateURLParams, actor *models.APIKey) (*models.URL, error) {
+	if params.OriginalURL == nil && params.ExpirationDate == nil && params.ActivatesAt == nil && params.FallbackURL == nil {
+		return nil, ErrNothingToUpdate
+	}
+	if params.OriginalURL != nil && !isHTTPURL(*params.OriginalURL) {
+		return nil, ErrInvalidURL
+	}
+	if params.FallbackURL != nil && *params.FallbackURL != "" && !isHTTPURL(*params.FallbackURL) {
+		return nil, ErrInvalidURL
+	}
+	if params.ExpirationDate != nil {
+		if _, err := s.expiry.expirationDate(*params.ExpirationDate); err != nil {
+			return nil, err
+		}
+	}
+
+	url, err := s.findByShortLink(shortLink)
 	if err != nil {
 		return nil, err
 	}
+	if !CanModify(actor, url) {
+		return nil, ErrForbidden
+	}
 
-	url.ExpirationDate = expirationDate
+	if params.OriginalURL != nil {
+		url.OriginalURL = *params.OriginalURL
+		url.DestinationHost = destinationHost(url.OriginalURL)
+	}
+	if params.ExpirationDate != nil {
+		url.ExpirationDate = *params.ExpirationDate
+	}
+	if params.FallbackURL != nil {
+		url.FallbackURL = *params.FallbackURL
+	}
+	if params.ActivatesAt != nil {
+		url.ActivatesAt = nil
+		if !params.ActivatesAt.IsZero() {
+			activatesAt := *params.ActivatesAt
+			url.ActivatesAt = &activatesAt
+		}
+	}
+	if url.ActivatesAt != nil && !url.ActivatesAt.Before(url.ExpirationDate) {
+		return nil, ErrInvalidActivation
+	}
 	if err := s.urlRepo.Update(url); err != nil {
 		return nil, err
 	}
 
 	return url, nil
 }
+
+func (s *urlService) Ping() error {
+	return s.urlRepo.Ping()
+}
+
+func isHTTPURL(url string) bool {
+	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")
+}
+
+// destinationHost is the lower-cased host of a destination URL, stored for domain filters.
+func destinationHost(rawURL string) string {
+	parsed, err := neturl.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(parsed.Hostname())
+}
+
+// findByShortLink translates repository lookups into service errors.
+func (s *urlService) findByShortLink(shortLink string) (*models.URL, error) {
+	url, err := s.urlRepo.FindByShortLink(shortLink)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrURLNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return url, nil
+}
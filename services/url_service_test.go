@@ -0,0 +1,152 @@
+package services
+
+import (
+	"testing"
+	"time"
+	"url-shortener/models"
+	"url-shortener/repositories"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateURL(t *testing.T) {
+	service := NewURLService(repositories.NewMemoryURLRepository(), nil, nil, ExpirationPolicy{})
+
+	url, err := service.CreateURL(CreateURLParams{OriginalURL: "https://example.com"})
+	require.NoError(t, err)
+	assert.Len(t, url.ShortLink, 6)
+	assert.WithinDuration(t, time.Now().Add(DefaultExpiration), url.ExpirationDate, time.Minute)
+	assert.Nil(t, url.OwnerID)
+
+	owner := &models.APIKey{ID: 9}
+	url, err = service.CreateURL(CreateURLParams{OriginalURL: "https://example.com", CustomSlug: "mine", Owner: owner})
+	require.NoError(t, err)
+	assert.Equal(t, "mine", url.ShortLink)
+	assert.Equal(t, &owner.ID, url.OwnerID)
+
+	_, err = service.CreateURL(CreateURLParams{OriginalURL: "https://example.com", CustomSlug: "mine"})
+	assert.ErrorIs(t, err, ErrSlugTaken)
+
+	_, err = service.CreateURL(CreateURLParams{OriginalURL: "ftp://example.com"})
+	assert.ErrorIs(t, err, ErrInvalidURL)
+
+	_, err = service.CreateURL(CreateURLParams{OriginalURL: "https://example.com", CustomSlug: "late", ExpirationDate: time.Now().Add(-time.Minute)})
+	assert.ErrorIs(t, err, ErrInvalidExpiration)
+}
+
+// collidingURLRepository rejects every insert as a duplicate, as if concurrent requests
+// always claimed the slug first.
+type collidingURLRepository struct {
+	repositories.URLRepository
+}
+
+func (collidingURLRepository) Create(url *models.URL) error {
+	return repositories.ErrDuplicateShortLink
+}
+
+func TestCreateURLCollisionsOnInsert(t *testing.T) {
+	service := NewURLService(collidingURLRepository{repositories.NewMemoryURLRepository()}, nil, nil, ExpirationPolicy{})
+	_, err := service.CreateURL(CreateURLParams{OriginalURL: "https://example.com"})
+	assert.ErrorIs(t, err, ErrSlugSpaceExhausted)
+	_, err = service.CreateURL(CreateURLParams{OriginalURL: "https://example.com", CustomSlug: "mine"})
+	assert.ErrorIs(t, err, ErrSlugTaken)
+}
+
+func TestUpdateURL(t *testing.T) {
+	service := NewURLService(repositories.NewMemoryURLRepository(), nil, nil, ExpirationPolicy{})
+	owner := &models.APIKey{ID: 1}
+	_, err := service.CreateURL(CreateURLParams{OriginalURL: "https://exmaple.com", CustomSlug: "edit", Owner: owner})
+	require.NoError(t, err)
+
+	destination := "https://example.com"
+	expiration := time.Now().Add(72 * time.Hour)
+	url, err := service.UpdateURL("edit", UpdateURLParams{OriginalURL: &destination, ExpirationDate: &expiration}, owner)
+	require.NoError(t, err)
+	assert.Equal(t, destination, url.OriginalURL)
+
+	stored, err := service.GetURL("edit")
+	require.NoError(t, err)
+	assert.Equal(t, destination, stored.OriginalURL)
+	assert.WithinDuration(t, expiration, stored.ExpirationDate, time.Second)
+
+	past := time.Now().Add(-time.Hour)
+	invalid := "mailto:someone@example.com"
+	tests := []struct {
+		name   string
+		params UpdateURLParams
+		actor  *models.APIKey
+		err    error
+	}{
+		{"empty", UpdateURLParams{}, owner, ErrNothingToUpdate},
+		{"bad url", UpdateURLParams{OriginalURL: &invalid}, owner, ErrInvalidURL},
+		{"past expiration", UpdateURLParams{ExpirationDate: &past}, owner, ErrInvalidExpiration},
+		{"other key", UpdateURLParams{OriginalURL: &destination}, &models.APIKey{ID: 2}, ErrForbidden},
+		{"anonymous", UpdateURLParams{OriginalURL: &destination}, nil, ErrForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.UpdateURL("edit", tt.params, tt.actor)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	_, err = service.UpdateURL("missing", UpdateURLParams{OriginalURL: &destination}, owner)
+	assert.ErrorIs(t, err, ErrURLNotFound)
+}
+
+func TestScheduledActivation(t *testing.T) {
+	service := NewURLService(repositories.NewMemoryURLRepository(), nil, nil, ExpirationPolicy{})
+	owner := &models.APIKey{ID: 1}
+	launch := time.Now().Add(time.Hour)
+	_, err := service.CreateURL(CreateURLParams{OriginalURL: "https://example.com", CustomSlug: "launch", ActivatesAt: launch, Owner: owner})
+	require.NoError(t, err)
+
+	_, err = service.GetURL("launch")
+	var notActive *NotActiveError
+	require.ErrorAs(t, err, &notActive)
+	assert.ErrorIs(t, err, ErrNotYetActive)
+	assert.True(t, launch.Equal(notActive.ActivatesAt))
+
+	// Activation must come before expiration, on creation and on update
+	_, err = service.CreateURL(CreateURLParams{OriginalURL: "https://example.com", ActivatesAt: time.Now().Add(48 * time.Hour)})
+	assert.ErrorIs(t, err, ErrInvalidActivation)
+	late := time.Now().Add(48 * time.Hour)
+	_, err = service.UpdateURL("launch", UpdateURLParams{ActivatesAt: &late}, owner)
+	assert.ErrorIs(t, err, ErrInvalidActivation)
+
+	now := time.Time{}
+	url, err := service.UpdateURL("launch", UpdateURLParams{ActivatesAt: &now}, owner)
+	require.NoError(t, err)
+	assert.Nil(t, url.ActivatesAt)
+	_, err = service.GetURL("launch")
+	assert.NoError(t, err)
+}
+
+func TestExpiredLinkFallback(t *testing.T) {
+	urlRepo := repositories.NewMemoryURLRepository()
+	service := NewURLService(urlRepo, nil, nil, ExpirationPolicy{})
+	require.NoError(t, urlRepo.Create(&models.URL{OriginalURL: "https://example.com", ShortLink: "ended", ExpirationDate: time.Now().Add(-time.Minute), FallbackURL: "https://example.com/over"}))
+	_, err := service.CreateURL(CreateURLParams{OriginalURL: "https://example.com", CustomSlug: "gone"})
+	require.NoError(t, err)
+
+	// The first request deletes the expired link; later ones find its tombstone
+	for i := 0; i < 2; i++ {
+		_, err = service.GetURL("ended")
+		var unavailable *UnavailableError
+		require.ErrorAs(t, err, &unavailable)
+		assert.ErrorIs(t, err, ErrURLExpired)
+		assert.Equal(t, "https://example.com/over", unavailable.FallbackURL)
+	}
+
+	require.NoError(t, service.DeleteURL("gone", &models.APIKey{IsAdmin: true}))
+	_, err = service.GetURL("gone")
+	var unavailable *UnavailableError
+	require.ErrorAs(t, err, &unavailable)
+	assert.ErrorIs(t, err, ErrURLDeleted)
+	_, err = service.GetURL("never")
+	assert.ErrorIs(t, err, ErrURLNotFound)
+
+	_, err = service.CreateURL(CreateURLParams{OriginalURL: "https://example.com", FallbackURL: "javascript:alert(1)"})
+	assert.ErrorIs(t, err, ErrInvalidURL)
+}
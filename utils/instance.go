@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+var (
+	instanceID     string
+	instanceIDOnce sync.Once
+)
+
+// InstanceID identifies this process (hostname and PID) when holding database leases.
+func InstanceID() string {
+	instanceIDOnce.Do(func() {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "unknown"
+		}
+		instanceID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	})
+	return instanceID
+}
@@ -1,20 +1,57 @@
 package utils
 
 import (
-	"math/rand"
-	"time"
+	"crypto/rand"
+	"errors"
+	"math/big"
 )
 
 const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
+// RandomString returns length characters drawn uniformly from alphabet using crypto/rand.
+func RandomString(alphabet string, length int) (string, error) {
+	if len(alphabet) == 0 || len(alphabet) > 256 {
+		return "", errors.New("alphabet must have between 1 and 256 characters")
+	}
+	// Bytes at or above limit are rejected so every character is equally likely
+	limit := 256 - 256%len(alphabet)
+	b := make([]byte, length)
+	buf := make([]byte, length+length/2+1)
+	for i := 0; i < length; {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, r := range buf {
+			if int(r) >= limit {
+				continue
+			}
+			b[i] = alphabet[int(r)%len(alphabet)]
+			if i++; i == length {
+				break
+			}
+		}
+	}
+	return string(b), nil
 }
 
+// GenerateRandomSlug returns a random alphanumeric string of the given length.
 func GenerateRandomSlug(length int) string {
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[rand.Intn(len(charset))]
+	slug, err := RandomString(charset, length)
+	if err != nil {
+		// crypto/rand only fails when the OS has no usable entropy source
+		panic(err)
+	}
+	return slug
+}
+
+// RandomIndex returns a uniformly distributed integer in [0, n) using crypto/rand.
+func RandomIndex(n int) (int, error) {
+	if n <= 0 {
+		return 0, errors.New("n must be positive")
+	}
+	i, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
 	}
-	return string(b)
+	return int(i.Int64()), nil
 }
@@ -0,0 +1,14 @@
+package utils
+
+import "time"
+
+// ParseTimestamp accepts an RFC 3339 timestamp or a YYYY-MM-DD date (midnight UTC); empty means unset.
+func ParseTimestamp(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ReferrerHost reduces a referrer to its host; visits without one count as "direct".
+func ReferrerHost(referrer string) string {
+	if referrer == "" {
+		return "direct"
+	}
+	parsed, err := url.Parse(referrer)
+	if err != nil || parsed.Host == "" {
+		return "unknown"
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Host), "www.")
+}
+
+// userAgentFamilies are checked in order, since e.g. Edge and Opera also claim to be Chrome
+// and Chrome claims to be Safari.
+var userAgentFamilies = []struct {
+	marker string
+	family string
+}{
+	{"bot", "Bot"},
+	{"crawler", "Bot"},
+	{"spider", "Bot"},
+	{"curl/", "curl"},
+	{"wget/", "Wget"},
+	{"edg/", "Edge"},
+	{"opr/", "Opera"},
+	{"samsungbrowser/", "Samsung Internet"},
+	{"firefox/", "Firefox"},
+	{"fxios/", "Firefox"},
+	{"crios/", "Chrome"},
+	{"chrome/", "Chrome"},
+	{"safari/", "Safari"},
+}
+
+// UserAgentFamily names the browser or client behind a User-Agent header.
+func UserAgentFamily(userAgent string) string {
+	if userAgent == "" {
+		return "Unknown"
+	}
+	lower := strings.ToLower(userAgent)
+	for _, candidate := range userAgentFamilies {
+		if strings.Contains(lower, candidate.marker) {
+			return candidate.family
+		}
+	}
+	return "Other"
+}